@@ -0,0 +1,78 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// activeHoursContain reports whether hour lies in the window from start (inclusive) to end (exclusive).
+// A window with start after end wraps across midnight; start equal to end covers the whole day.
+func activeHoursContain(start, end, hour int) bool {
+	switch {
+	case start == end:
+		return true
+	case start < end:
+		return hour >= start && hour < end
+	default:
+		return hour >= start || hour < end
+	}
+}
+
+// inActiveHours reports whether t lies within the configured active hours in the configured time zone.
+// While the heating is on, the temperature is always monitored.
+func (hm *HeatingManager) inActiveHours(t time.Time) bool {
+	config := hm.currentConfig()
+	hm.mu.Lock()
+	heatingOn := hm.heatingOn
+	hm.mu.Unlock()
+	return heatingOn || activeHoursContain(config.ActiveHoursStart, config.ActiveHoursEnd, t.In(config.location()).Hour())
+}
+
+// untilActiveHours returns the time from t until the start of the next active window.
+func (hm *HeatingManager) untilActiveHours(t time.Time) time.Duration {
+	config := hm.currentConfig()
+	t = t.In(config.location())
+	start := time.Date(t.Year(), t.Month(), t.Day(), config.ActiveHoursStart, 0, 0, 0, t.Location())
+	if !start.After(t) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start.Sub(t)
+}
+
+// inactiveTime returns how much of the time from since to until lies outside the active hours.
+func (hm *HeatingManager) inactiveTime(since, until time.Time) time.Duration {
+	config := hm.currentConfig()
+	var inactive time.Duration
+	for t := since.In(config.location()); t.Before(until); {
+		next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		if next.After(until) {
+			next = until
+		}
+		if !activeHoursContain(config.ActiveHoursStart, config.ActiveHoursEnd, t.Hour()) {
+			inactive += next.Sub(t)
+		}
+		t = next
+	}
+	return inactive
+}
+
+// pollInterval returns the interval until the next temperature check: the check interval within the
+// active hours, InactiveCheckInterval outside of them, but no later than the start of the active window.
+func (hm *HeatingManager) pollInterval() time.Duration {
+	inactiveInterval := time.Duration(hm.currentConfig().InactiveCheckInterval) * time.Minute
+	now := hm.Clock.Now()
+	if inactiveInterval == 0 || hm.inActiveHours(now) {
+		return hm.checkInterval()
+	}
+	return min(inactiveInterval, hm.untilActiveHours(now))
+}
+
+// shouldCheckTemperature reports whether a scheduled temperature check runs.
+// Outside the active hours, checks are skipped unless InactiveCheckInterval is set.
+func (hm *HeatingManager) shouldCheckTemperature() bool {
+	if hm.currentConfig().InactiveCheckInterval > 0 || hm.inActiveHours(hm.Clock.Now()) {
+		return true
+	}
+	slog.Debug("Skipping temperature check outside active hours")
+	return false
+}
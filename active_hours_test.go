@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveHoursContain(t *testing.T) {
+	tests := []struct {
+		start, end, hour int
+		want             bool
+	}{
+		{7, 20, 6, false},
+		{7, 20, 7, true},
+		{7, 20, 19, true},
+		{7, 20, 20, false},
+		{22, 6, 23, true},
+		{22, 6, 0, true},
+		{22, 6, 5, true},
+		{22, 6, 6, false},
+		{22, 6, 12, false},
+		{0, 0, 3, true},
+	}
+	for _, tt := range tests {
+		if got := activeHoursContain(tt.start, tt.end, tt.hour); got != tt.want {
+			t.Errorf("activeHoursContain(%d, %d, %d) = %v, want %v", tt.start, tt.end, tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestPollInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.Local)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.ActiveHoursStart = 7
+	manager.Config.ActiveHoursEnd = 20
+	manager.Config.InactiveCheckInterval = 60
+
+	if d := manager.pollInterval(); d != manager.checkInterval() {
+		t.Errorf("Expected the check interval within the active hours, got %v", d)
+	}
+	clock.now = time.Date(2024, 3, 6, 22, 0, 0, 0, time.Local)
+	if d := manager.pollInterval(); d != time.Hour {
+		t.Errorf("Expected the inactive interval at night, got %v", d)
+	}
+	clock.now = time.Date(2024, 3, 7, 6, 30, 0, 0, time.Local)
+	if d := manager.pollInterval(); d != 30*time.Minute {
+		t.Errorf("Expected the next check at the start of the active hours, got %v", d)
+	}
+
+	manager.heatingOn = true
+	if d := manager.pollInterval(); d != manager.checkInterval() {
+		t.Errorf("Expected the check interval while heating, got %v", d)
+	}
+}
+
+func TestShouldCheckTemperature(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 2, 0, 0, 0, time.Local)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.ActiveHoursStart = 22
+	manager.Config.ActiveHoursEnd = 6
+
+	if !manager.shouldCheckTemperature() {
+		t.Error("Expected a check within an active window wrapping across midnight")
+	}
+	clock.now = time.Date(2024, 3, 6, 12, 0, 0, 0, time.Local)
+	if manager.shouldCheckTemperature() {
+		t.Error("Expected checks to be skipped outside the active hours")
+	}
+	manager.Config.InactiveCheckInterval = 30
+	if !manager.shouldCheckTemperature() {
+		t.Error("Expected a check outside the active hours with an inactive check interval")
+	}
+}
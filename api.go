@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// statusResponse is the JSON body returned by GET /status.
+type statusResponse struct {
+	Zone                string         `json:"zone"`
+	Temperature         float64        `json:"temperature"`
+	LastReadTime        time.Time      `json:"lastReadTime"`
+	Threshold           float64        `json:"threshold"`
+	LastCheck           *time.Time     `json:"lastCheck"`
+	TemperatureExceeded bool           `json:"temperatureExceeded"`
+	SkipNextWeekly      bool           `json:"skipNextWeekly"`
+	VacationMode        bool           `json:"vacationMode"`
+	Stats               Stats          `json:"stats"`
+	Cooldown            *Cooldown      `json:"cooldown,omitempty"`
+	LastWeeklyOutcome   *WeeklyOutcome `json:"lastWeeklyOutcome"`
+	HeatingFailedAt     *time.Time     `json:"heatingFailedAt,omitempty"`
+}
+
+// StartAPIServer serves the REST API until the context is cancelled.
+// It does nothing if no API port is configured.
+func (hm *HeatingManager) StartAPIServer(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.APIPort == 0 {
+		return
+	}
+	serveHTTP(ctx, fmt.Sprintf(":%d", config.APIPort), config.HTTPBasePath, hm.requireBasicAuth(hm.apiHandler()))
+}
+
+// apiHandler returns the handler for all REST API endpoints.
+func (hm *HeatingManager) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", hm.handleDashboard)
+	mux.HandleFunc("GET /status", hm.handleStatus)
+	mux.HandleFunc("GET /metrics/temperature", hm.withoutZones((*HeatingManager).handleTemperatureHistory))
+	mux.HandleFunc("GET /zones", hm.handleZones)
+	mux.HandleFunc("GET /zones/{zone}/metrics/temperature", hm.forZone((*HeatingManager).handleTemperatureHistory))
+	mux.Handle("GET /config", hm.requireToken(http.HandlerFunc(hm.handleConfig)))
+	mux.Handle("GET /diag/shelly-temp", hm.requireToken(http.HandlerFunc(hm.handleDiagShellyTemp)))
+	mux.Handle("POST /heating/run", hm.requireToken(hm.withoutZones((*HeatingManager).handleHeatingRun)))
+	mux.Handle("POST /zones/{zone}/heating/run", hm.requireToken(hm.forZone((*HeatingManager).handleHeatingRun)))
+	mux.Handle("POST /heating/skip-next", hm.requireToken(hm.withoutZones((*HeatingManager).handleSkipNext)))
+	mux.Handle("DELETE /heating/skip-next", hm.requireToken(hm.withoutZones((*HeatingManager).handleSkipNext)))
+	mux.Handle("POST /zones/{zone}/heating/skip-next", hm.requireToken(hm.forZone((*HeatingManager).handleSkipNext)))
+	mux.Handle("DELETE /zones/{zone}/heating/skip-next", hm.requireToken(hm.forZone((*HeatingManager).handleSkipNext)))
+	mux.Handle("POST /vacation", hm.requireToken(http.HandlerFunc(hm.handleVacation)))
+	mux.Handle("DELETE /vacation", hm.requireToken(http.HandlerFunc(hm.handleVacation)))
+	return mux
+}
+
+// handleStatus reports the current temperature, weekly check state and operational statistics.
+// With zones, it reports the status of every zone like GET /zones.
+func (hm *HeatingManager) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if len(hm.zones) > 0 {
+		hm.handleZones(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, hm.status())
+}
+
+// status returns the current temperature, weekly check state and operational statistics.
+func (hm *HeatingManager) status() statusResponse {
+	config := hm.currentConfig()
+	temperature, readTime := hm.CurrentTemperature()
+	response := statusResponse{
+		Zone:                hm.zoneName(),
+		Temperature:         temperature,
+		LastReadTime:        readTime,
+		Threshold:           config.TemperatureThreshold,
+		TemperatureExceeded: hm.isTemperatureExceeded(),
+		SkipNextWeekly:      hm.isSkipNextWeekly(),
+		VacationMode:        hm.isVacationMode(),
+		Stats:               hm.Stats(),
+		Cooldown:            hm.Cooldown(),
+		LastWeeklyOutcome:   hm.LastWeeklyOutcome(),
+	}
+	if lastCheck, err := hm.readLastCheckTime(); err == nil {
+		response.LastCheck = &lastCheck
+	}
+	hm.mu.Lock()
+	if !hm.heatingFailedAt.IsZero() {
+		failedAt := hm.heatingFailedAt
+		response.HeatingFailedAt = &failedAt
+	}
+	hm.mu.Unlock()
+	return response
+}
+
+// temperatureHistoryResponse is the JSON body returned by GET /metrics/temperature.
+type temperatureHistoryResponse struct {
+	Unit     string    `json:"unit"`
+	Readings []Reading `json:"readings"`
+}
+
+// handleTemperatureHistory returns the most recent temperature readings in Celsius, oldest first.
+// The query parameter limit restricts the response to the last N readings.
+func (hm *HeatingManager) handleTemperatureHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be a non-negative integer"})
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, http.StatusOK, temperatureHistoryResponse{Unit: unitCelsius, Readings: hm.RecentReadings(limit)})
+}
+
+// redactedValue replaces secrets in the config returned by GET /config.
+const redactedValue = "[REDACTED]"
+
+// redacted returns a copy of the config with passwords, tokens and webhook URLs replaced by redactedValue,
+// and the passwords of credentials embedded in the other URLs masked.
+func (c Config) redacted() Config {
+	for _, secret := range []*string{&c.ShellyPassword, &c.APIToken, &c.TelegramBotToken, &c.SMTPPassword, &c.MQTTPassword, &c.InfluxToken, &c.SlackWebhookURL, &c.NotifyURL, &c.HTTPAuthPassword} {
+		if *secret != "" {
+			*secret = redactedValue
+		}
+	}
+	for _, u := range []*string{&c.ShellyURL, &c.ShellyHeatingOnURL, &c.ShellyHeatingOffURL, &c.ShellyRelayURL, &c.ShellyStatusURL, &c.ShellyPowerURL, &c.ShellyTempFallbackURL, &c.ShellyWebSocketURL, &c.PVSurplusURL, &c.BatterySOCURL, &c.InfluxURL, &c.MQTTBroker} {
+		*u = redactURL(*u)
+	}
+	// The slices and the map are shared with the active config and therefore copied.
+	c.ShellyURLs = redactURLs(c.ShellyURLs)
+	if c.SensorOffsets != nil {
+		offsets := make(map[string]float64, len(c.SensorOffsets))
+		for u, offset := range c.SensorOffsets {
+			offsets[redactURL(u)] = offset
+		}
+		c.SensorOffsets = offsets
+	}
+	if c.Zones != nil {
+		zones := make([]ZoneConfig, len(c.Zones))
+		for i, zone := range c.Zones {
+			for _, u := range []*string{&zone.ShellyURL, &zone.ShellyHeatingOnURL, &zone.ShellyHeatingOffURL, &zone.ShellyRelayURL, &zone.ShellyStatusURL, &zone.ShellyPowerURL, &zone.ShellyWebSocketURL} {
+				*u = redactURL(*u)
+			}
+			zone.ShellyURLs = redactURLs(zone.ShellyURLs)
+			zones[i] = zone
+		}
+		c.Zones = zones
+	}
+	return c
+}
+
+// redactURL masks the password of credentials embedded in rawURL; other URLs are returned unchanged.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// redactURLs returns a copy of urls with redactURL applied to each of them.
+func redactURLs(urls []string) []string {
+	if urls == nil {
+		return nil
+	}
+	redacted := make([]string, len(urls))
+	for i, u := range urls {
+		redacted[i] = redactURL(u)
+	}
+	return redacted
+}
+
+// handleConfig returns the active configuration after environment overrides and reloads, with secrets redacted.
+func (hm *HeatingManager) handleConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, hm.currentConfig().redacted())
+}
+
+// handleHeatingRun runs the weekly check logic immediately.
+// The query parameter force=true overrides the minimum heating interval.
+func (hm *HeatingManager) handleHeatingRun(w http.ResponseWriter, r *http.Request) {
+	ctx := withCorrelationID(r.Context())
+	config := hm.currentConfig()
+	slog.InfoContext(ctx, "Manual heating run triggered", "remote", r.RemoteAddr)
+	force := r.URL.Query().Get("force") == "true"
+	if err := hm.weeklyCheck(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL), force); err != nil {
+		slog.ErrorContext(ctx, "Manual heating run failed", "err", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	response := map[string]string{"status": "done"}
+	if outcome := hm.LastWeeklyOutcome(); outcome != nil {
+		response["result"] = outcome.Result
+		response["reason"] = outcome.Reason
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleSkipNext sets the manual override skipping the next weekly heating on POST and clears it on DELETE.
+func (hm *HeatingManager) handleSkipNext(w http.ResponseWriter, r *http.Request) {
+	skip := r.Method == http.MethodPost
+	slog.Info("Manual skip of the next weekly heating changed", "skip", skip, "remote", r.RemoteAddr)
+	if err := hm.SetSkipNextWeekly(skip); err != nil {
+		slog.Error("Failed to save the skip override", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"skipNextWeekly": skip})
+}
+
+// handleVacation enables vacation mode on POST and disables it on DELETE.
+func (hm *HeatingManager) handleVacation(w http.ResponseWriter, r *http.Request) {
+	on := r.Method == http.MethodPost
+	slog.Info("Vacation mode changed via API", "vacationMode", on, "remote", r.RemoteAddr)
+	if err := hm.SetVacationMode(on); err != nil {
+		slog.Error("Failed to save vacation mode", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"vacationMode": on})
+}
+
+// requireToken rejects requests without the configured bearer token.
+// Without a configured token all requests are let through. With Basic Auth configured,
+// its credentials are accepted as well, since a request carries only one Authorization header.
+func (hm *HeatingManager) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := hm.currentConfig()
+		if config.APIToken != "" && !bearerAuthorized(r, config) && !(config.HTTPAuthUser != "" && basicAuthorized(r, config)) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write JSON response", "err", err)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// basicAuthRealm is the realm announced when Basic Auth credentials are missing or wrong.
+const basicAuthRealm = "pv_heating_manager"
+
+// requireBasicAuth rejects requests without the configured Basic Auth credentials.
+// Without a configured user all requests are let through. A valid apiToken bearer token is accepted
+// instead, so token clients keep working once Basic Auth is enabled.
+func (hm *HeatingManager) requireBasicAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := hm.currentConfig()
+		if config.HTTPAuthUser != "" && !basicAuthorized(r, config) && !bearerAuthorized(r, config) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+basicAuthRealm+`", charset="UTF-8"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerAuthorized reports whether the request carries the configured API token as bearer token.
+func bearerAuthorized(r *http.Request, config Config) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && config.APIToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(config.APIToken)) == 1
+}
+
+// basicAuthorized reports whether the request carries the configured Basic Auth credentials.
+// User and password are both compared in constant time, so the response time reveals neither.
+func basicAuthorized(r *http.Request, config Config) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(config.HTTPAuthUser))
+	passwordOK := subtle.ConstantTimeCompare([]byte(password), []byte(config.HTTPAuthPassword))
+	return userOK&passwordOK == 1
+}
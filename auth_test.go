@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireBasicAuth(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HTTPAuthUser = "admin"
+	manager.Config.HTTPAuthPassword = "secret"
+	handler := manager.requireBasicAuth(manager.apiHandler())
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		want     int
+	}{
+		{"no credentials", "", "", http.StatusUnauthorized},
+		{"wrong password", "admin", "guess", http.StatusUnauthorized},
+		{"wrong user", "root", "secret", http.StatusUnauthorized},
+		{"valid credentials", "admin", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/status", nil)
+		if tt.user != "" {
+			req.SetBasicAuth(tt.user, tt.password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, rec.Code)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected a WWW-Authenticate challenge", tt.name)
+		}
+	}
+}
+
+func TestRequireBasicAuthDisabled(t *testing.T) {
+	manager := newTestManager(t)
+	rec := httptest.NewRecorder()
+	manager.requireBasicAuth(manager.apiHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 without configured credentials, got %d", rec.Code)
+	}
+}
+
+func TestBasicAuthAuthorizesTokenEndpoints(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.APIToken = "token"
+	manager.Config.HTTPAuthUser = "admin"
+	manager.Config.HTTPAuthPassword = "secret"
+	handler := manager.requireBasicAuth(manager.apiHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.SetBasicAuth("admin", "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the Basic Auth credentials to authorize GET /config, got %d", rec.Code)
+	}
+}
+
+func TestBearerTokenPassesBasicAuth(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.APIToken = "token"
+	manager.Config.HTTPAuthUser = "admin"
+	manager.Config.HTTPAuthPassword = "secret"
+	handler := manager.requireBasicAuth(manager.apiHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the bearer token to authorize GET /config with Basic Auth enabled, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", rec.Code)
+	}
+}
+
+func TestHealthHandlerExcludedFromBasicAuth(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HTTPAuthUser = "admin"
+	manager.Config.HTTPAuthPassword = "secret"
+
+	rec := httptest.NewRecorder()
+	manager.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for /healthz behind Basic Auth, got %d", rec.Code)
+	}
+
+	manager.Config.HTTPAuthExcludeHealthz = true
+	rec = httptest.NewRecorder()
+	manager.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code == http.StatusUnauthorized {
+		t.Error("Expected /healthz to be served without credentials when excluded")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// errHeatingDeferred is returned by weeklyCheck when the heating is deferred because the battery is low.
+var errHeatingDeferred = errors.New("heating deferred, battery state of charge below minimum")
+
+// batteryRetryDelay is the delay before a weekly check deferred for the battery is retried.
+var batteryRetryDelay = 15 * time.Minute
+
+// getBatterySOC reads the battery state of charge in percent.
+func (hm *HeatingManager) getBatterySOC(ctx context.Context, batterySOCURL string) (float64, error) {
+	soc, err := hm.getNumber(ctx, batterySOCURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get battery SOC: %w", err)
+	}
+	return soc, nil
+}
+
+// batteryLow reports whether the battery state of charge is below MinBatterySOC.
+// Without a configured battery, or if the state of charge cannot be read, the battery never counts as low.
+func (hm *HeatingManager) batteryLow(ctx context.Context) (bool, float64) {
+	config := hm.currentConfig()
+	if config.BatterySOCURL == "" {
+		return false, 0
+	}
+	soc, err := hm.getBatterySOC(ctx, config.BatterySOCURL)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read battery state of charge, not deferring heating", "err", err)
+		return false, 0
+	}
+	return soc < config.MinBatterySOC, soc
+}
+
+// deferForBattery reports whether the weekly heating should be deferred because the battery is low.
+// Once the heating has been deferred for MaxBatteryDeferralHours, it runs anyway.
+func (hm *HeatingManager) deferForBattery(ctx context.Context) bool {
+	config := hm.currentConfig()
+	low, soc := hm.batteryLow(ctx)
+	if !low {
+		return false
+	}
+
+	now := hm.Clock.Now()
+	hm.mu.Lock()
+	if hm.batteryDeferredSince.IsZero() {
+		hm.batteryDeferredSince = now
+	}
+	deferredSince := hm.batteryDeferredSince
+	hm.mu.Unlock()
+
+	maxDeferral := time.Duration(config.MaxBatteryDeferralHours) * time.Hour
+	if now.Sub(deferredSince) >= maxDeferral {
+		slog.WarnContext(ctx, "Battery state of charge still below minimum, heating anyway after the maximum deferral", "soc", soc, "minSOC", config.MinBatterySOC, "deferredSince", deferredSince, "maxDeferral", maxDeferral)
+		return false
+	}
+	slog.InfoContext(ctx, "Deferring heating, battery state of charge below minimum", "soc", soc, "minSOC", config.MinBatterySOC, "deferredSince", deferredSince, "retryIn", batteryRetryDelay)
+	return true
+}
+
+// resetBatteryDeferral ends the deferral window after the weekly heating ran.
+func (hm *HeatingManager) resetBatteryDeferral() {
+	hm.mu.Lock()
+	hm.batteryDeferredSince = time.Time{}
+	hm.mu.Unlock()
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWeeklyCheckDefersForBattery(t *testing.T) {
+	soc := "20"
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/soc":
+			_, _ = w.Write([]byte(soc))
+		case "/on":
+			onCalls.Add(1)
+		}
+	}))
+	defer ts.Close()
+
+	clock := &fakeClock{now: time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.BatterySOCURL = ts.URL + "/soc"
+	manager.Config.MinBatterySOC = 30
+	manager.Config.MaxBatteryDeferralHours = 24
+
+	err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false)
+	if !errors.Is(err, errHeatingDeferred) {
+		t.Fatalf("Expected errHeatingDeferred, got %v", err)
+	}
+	clock.Advance(12 * time.Hour)
+	err = manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false)
+	if !errors.Is(err, errHeatingDeferred) {
+		t.Fatalf("Expected errHeatingDeferred within the deferral window, got %v", err)
+	}
+	if onCalls.Load() != 0 {
+		t.Fatalf("Expected no heating while deferred, got %d on calls", onCalls.Load())
+	}
+
+	clock.Advance(12 * time.Hour)
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("Expected heating after the maximum deferral, got %v", err)
+	}
+	defer manager.cancelHeatingOff()
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected heating to be turned on once, got %d on calls", onCalls.Load())
+	}
+	if !manager.batteryDeferredSince.IsZero() {
+		t.Error("Expected the deferral window to be reset after the heating ran")
+	}
+}
+
+func TestWeeklyCheckBatteryCharged(t *testing.T) {
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/soc":
+			_, _ = w.Write([]byte("80.5\n"))
+		case "/on":
+			onCalls.Add(1)
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.BatterySOCURL = ts.URL + "/soc"
+	manager.Config.MinBatterySOC = 30
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck failed: %v", err)
+	}
+	defer manager.cancelHeatingOff()
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected heating to be turned on once, got %d on calls", onCalls.Load())
+	}
+}
+
+func TestBatteryLowUnreadable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.BatterySOCURL = ts.URL
+	manager.Config.MinBatterySOC = 30
+
+	if low, _ := manager.batteryLow(context.Background()); low {
+		t.Error("Expected an unreadable battery not to count as low")
+	}
+}
+
+func TestControlPVSurplusBatteryLow(t *testing.T) {
+	var onCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/surplus":
+			_, _ = w.Write([]byte("2500"))
+		case "/soc":
+			_, _ = w.Write([]byte("10"))
+		case "/on":
+			onCalls++
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.PVSurplusURL = ts.URL + "/surplus"
+	manager.Config.PVSurplusThresholdWatts = 2000
+	manager.Config.BatterySOCURL = ts.URL + "/soc"
+	manager.Config.MinBatterySOC = 30
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+
+	manager.controlPVSurplus(context.Background())
+	if onCalls != 0 || manager.pvHeating {
+		t.Errorf("Expected no PV heating while the battery is low, got %d on calls", onCalls)
+	}
+}
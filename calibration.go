@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxTemperatureOffset is the largest accepted calibration offset in °C, either way.
+const maxTemperatureOffset = 10.0
+
+// temperatureOffset returns the calibration offset of the sensor at url in the configured unit.
+// An entry in SensorOffsets takes precedence over TemperatureOffset.
+func (c *Config) temperatureOffset(url string) float64 {
+	offset, ok := c.SensorOffsets[url]
+	if !ok {
+		offset = c.TemperatureOffset
+	}
+	if c.TemperatureUnit == unitFahrenheit {
+		return offset * 9 / 5
+	}
+	return offset
+}
+
+// validateOffsets rejects calibration offsets larger than maxTemperatureOffset.
+func (c *Config) validateOffsets() error {
+	if math.Abs(c.TemperatureOffset) > maxTemperatureOffset {
+		return fmt.Errorf("invalid config: temperatureOffset must be within ±%g °C, got %g", maxTemperatureOffset, c.TemperatureOffset)
+	}
+	for url, offset := range c.SensorOffsets {
+		if math.Abs(offset) > maxTemperatureOffset {
+			return fmt.Errorf("invalid config: sensorOffsets for %q must be within ±%g °C, got %g", url, maxTemperatureOffset, offset)
+		}
+	}
+	return nil
+}
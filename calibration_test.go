@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetTemperatureOffset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tC": 50.0, "tF": 122.0}`))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.TemperatureOffset = 1.5
+	manager.Config.SensorOffsets = map[string]float64{ts.URL + "/calibrated": -0.5}
+
+	tests := []struct {
+		unit string
+		path string
+		want float64
+	}{
+		{unitCelsius, "/temp", 51.5},
+		{unitCelsius, "/calibrated", 49.5},
+		{unitFahrenheit, "/temp", 124.7},
+		{unitFahrenheit, "/calibrated", 121.1},
+	}
+	for _, tt := range tests {
+		manager.Config.TemperatureUnit = tt.unit
+		got, err := manager.getTemperature(context.Background(), ts.URL+tt.path)
+		if err != nil {
+			t.Fatalf("getTemperature returned an error: %v", err)
+		}
+		if got = manager.Config.roundTemperature(got); got != tt.want {
+			t.Errorf("%s in %s: expected %v, got %v", tt.path, tt.unit, tt.want, got)
+		}
+	}
+}
+
+func TestReadTemperatureOffsetPerSensor(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/top":
+			_, _ = w.Write([]byte(`{"tC": 55.0}`))
+		case "/bottom":
+			_, _ = w.Write([]byte(`{"tC": 54.0}`))
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.SensorOffsets = map[string]float64{ts.URL + "/bottom": 2}
+
+	temperature, _, err := manager.readTemperature(context.Background(), []string{ts.URL + "/top", ts.URL + "/bottom"})
+	if err != nil {
+		t.Fatalf("readTemperature returned an error: %v", err)
+	}
+	if temperature != 56 {
+		t.Errorf("Expected the calibrated bottom sensor to be the maximum 56, got %v", temperature)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// runConfigCheck validates the config file at configPath, reads every configured temperature sensor once
+// and reports what a heating run would switch, without switching anything.
+// It writes a report to w and returns false if any check failed.
+func runConfigCheck(ctx context.Context, w io.Writer, configPath string) bool {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(w, "FAIL config %s: %v\n", configPath, err)
+		return false
+	}
+	client, err := newHTTPClient(config)
+	if err != nil {
+		fmt.Fprintf(w, "FAIL HTTP client: %v\n", err)
+		return false
+	}
+	fmt.Fprintf(w, "OK   config %s\n", configPath)
+
+	if len(config.Zones) == 0 {
+		return checkDevices(ctx, w, config, client)
+	}
+	ok := true
+	for _, zone := range config.Zones {
+		fmt.Fprintf(w, "---- zone %s\n", zone.Name)
+		zoneConfig := config.zoneConfig(zone)
+		zoneConfig.setDefaults()
+		if !checkDevices(ctx, w, zoneConfig, client) {
+			ok = false
+		}
+	}
+	return ok
+}
+
+// checkDevices reads the temperature sensors, relay status and power meter of config once
+// and lists the heating URLs that would be called. It returns false if any check failed.
+func checkDevices(ctx context.Context, w io.Writer, config Config, client HTTPClient) bool {
+	// The state file is left alone, the check only talks to the configured devices.
+	manager := &HeatingManager{Config: config, HTTPClient: client, Clock: realClock{}}
+	ok := true
+
+	sensors := config.ShellyURLs
+	if config.ShellyTempFallbackURL != "" {
+		sensors = append(sensors[:len(sensors):len(sensors)], config.ShellyTempFallbackURL)
+	}
+	for _, sensorURL := range sensors {
+		temperature, err := manager.getTemperature(ctx, sensorURL)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL temperature %s: %v\n", sensorURL, err)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK   temperature %s: %s °%s\n", sensorURL, config.formatTemperature(temperature), config.TemperatureUnit)
+	}
+
+	if config.ShellyStatusURL != "" {
+		on, err := manager.getRelayStatus(ctx, config.ShellyStatusURL)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL relay status %s: %v\n", config.ShellyStatusURL, err)
+			ok = false
+		} else {
+			fmt.Fprintf(w, "OK   relay status %s: on=%v\n", config.ShellyStatusURL, on)
+		}
+	}
+
+	if config.ShellyPowerURL != "" {
+		watts, err := manager.getPower(ctx, config.ShellyPowerURL)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL power %s: %v\n", config.ShellyPowerURL, err)
+			ok = false
+		} else {
+			fmt.Fprintf(w, "OK   power %s: %.1f W\n", config.ShellyPowerURL, watts)
+		}
+	}
+
+	for _, switchURL := range []string{config.ShellyHeatingOnURL, config.ShellyHeatingOffURL} {
+		if u, err := url.Parse(switchURL); err != nil || u.Host == "" {
+			fmt.Fprintf(w, "FAIL heating URL %s: not an absolute URL\n", switchURL)
+			ok = false
+			continue
+		}
+		fmt.Fprintf(w, "OK   [DRY-RUN] would call %s\n", switchURL)
+	}
+	return ok
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRunConfigCheck(t *testing.T) {
+	var switched bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/temp":
+			_, _ = w.Write([]byte(`{"id":100,"tC":51}`))
+		case "/on", "/off":
+			switched = true
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	config := newTestManager(t).currentConfig()
+	config.ShellyURLs = []string{ts.URL + "/temp"}
+	config.ShellyHeatingOnURL = ts.URL + "/on"
+	config.ShellyHeatingOffURL = ts.URL + "/off"
+
+	var report bytes.Buffer
+	if !runConfigCheck(context.Background(), &report, writeTestConfig(t, config)) {
+		t.Errorf("Expected the check to pass, report:\n%s", report.String())
+	}
+	if !strings.Contains(report.String(), "51.0 °C") {
+		t.Errorf("Expected the temperature in the report, got:\n%s", report.String())
+	}
+	if switched {
+		t.Error("Expected the check not to switch the heating")
+	}
+
+	config.ShellyURLs = []string{ts.URL + "/missing"}
+	config.MaxRetries = 1
+	config.RetryBackoff = 1
+	report.Reset()
+	if runConfigCheck(context.Background(), &report, writeTestConfig(t, config)) {
+		t.Errorf("Expected the check to fail for an unreachable sensor, report:\n%s", report.String())
+	}
+}
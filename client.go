@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// HTTPClient sends HTTP requests. *http.Client satisfies it; tests can inject stubs.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// httpGet issues a GET request through the manager's HTTP client.
+// A request without a response fails with ErrDeviceUnreachable.
+func (hm *HeatingManager) httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := hm.do(req)
+	if err != nil {
+		return nil, unreachable(err)
+	}
+	return resp, nil
+}
+
+// httpPost issues a POST request with the given content type through the manager's HTTP client.
+func (hm *HeatingManager) httpPost(url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return hm.do(req)
+}
+
+// do sends a request through the manager's HTTP client with the configured User-Agent.
+func (hm *HeatingManager) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", hm.currentConfig().userAgent())
+	return hm.HTTPClient.Do(req)
+}
+
+// userAgent returns the User-Agent header value for outgoing requests.
+func (c Config) userAgent() string {
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	return "pv_heating_manager/" + version
+}
+
+// newHTTPClient creates the shared HTTP client with the configured timeout and TLS settings.
+func newHTTPClient(config Config) (*http.Client, error) {
+	client := &http.Client{Timeout: time.Duration(config.HTTPTimeout) * time.Second}
+	tlsConfig, err := shellyTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	client.Transport = transport
+	return client, nil
+}
+
+// shellyTLSConfig returns the TLS settings for Shelly connections, or nil if the defaults apply.
+func shellyTLSConfig(config Config) (*tls.Config, error) {
+	if !config.InsecureSkipTLSVerify && config.ShellyCACert == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipTLSVerify}
+	if config.ShellyCACert != "" {
+		pem, err := os.ReadFile(config.ShellyCACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse CA certificate %s", config.ShellyCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
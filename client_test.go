@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPClientTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{"default verification", Config{}, true},
+		{"skip verification", Config{InsecureSkipTLSVerify: true}, false},
+		{"custom CA", Config{ShellyCACert: caFile}, false},
+	}
+	for _, tt := range tests {
+		client, err := newHTTPClient(tt.config)
+		if err != nil {
+			t.Fatalf("%s: newHTTPClient returned an error: %v", tt.name, err)
+		}
+		resp, err := client.Get(ts.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestNewHTTPClientInvalidCA(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+	if _, err := newHTTPClient(Config{ShellyCACert: caFile}); err == nil {
+		t.Error("Expected an error for an invalid CA file")
+	}
+}
+
+func TestHTTPGetUserAgent(t *testing.T) {
+	var userAgent string
+	manager := newTestManager(t)
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		userAgent = req.Header.Get("User-Agent")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}}
+
+	resp, err := manager.httpGet(context.Background(), "http://shelly/temp")
+	if err != nil {
+		t.Fatalf("httpGet returned an error: %v", err)
+	}
+	resp.Body.Close()
+	if userAgent != "pv_heating_manager/"+version {
+		t.Errorf("Expected the default User-Agent, got %q", userAgent)
+	}
+
+	manager.Config.UserAgent = "boiler/1.0"
+	resp, err = manager.httpGet(context.Background(), "http://shelly/temp")
+	if err != nil {
+		t.Fatalf("httpGet returned an error: %v", err)
+	}
+	resp.Body.Close()
+	if userAgent != "boiler/1.0" {
+		t.Errorf("Expected the configured User-Agent, got %q", userAgent)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// Clock tells the current time. The real clock is used by default; tests can inject a fake one.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the Clock backed by time.Now.
+type realClock struct{}
+
+// Now returns the current local time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
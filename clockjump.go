@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+)
+
+// clockJumpThreshold is the discrepancy between the clock and the elapsed time that is reported as a clock jump.
+const clockJumpThreshold = 5 * time.Minute
+
+// weeklyRecheckInterval bounds how long the weekly check loop sleeps before recomputing the next check time,
+// so a clock change, e.g. an NTP correction or a suspended VM, delays the check by at most this long.
+var weeklyRecheckInterval = time.Hour
+
+// clockWatch detects jumps of a clock by comparing it with the monotonic time elapsed in between.
+type clockWatch struct {
+	clock Clock
+	wall  time.Time
+	mono  time.Time
+}
+
+// newClockWatch starts watching the clock.
+func newClockWatch(clock Clock) *clockWatch {
+	w := &clockWatch{clock: clock}
+	w.jump()
+	return w
+}
+
+// jump returns how much further the clock moved than the monotonic time since the previous call.
+func (w *clockWatch) jump() time.Duration {
+	// Round(0) strips the monotonic reading, so the difference is taken on the wall clock.
+	wall, mono := w.clock.Now().Round(0), time.Now()
+	jump := wall.Sub(w.wall) - mono.Sub(w.mono)
+	w.wall, w.mono = wall, mono
+	return jump
+}
+
+// weeklyCheckDelay returns how long the weekly check loop sleeps: the time until the next weekly check,
+// at most weeklyRecheckInterval. A last check in the future is clamped to now first.
+func (hm *HeatingManager) weeklyCheckDelay() time.Duration {
+	hm.clampLastCheck()
+	return min(hm.nextWeeklyCheckDuration(), weeklyRecheckInterval)
+}
+
+// clampLastCheck resets a last check time in the future to now. After the clock was set back, the next
+// check would otherwise wait for the recorded time to come around again.
+func (hm *HeatingManager) clampLastCheck() {
+	now := hm.Clock.Now()
+	hm.mu.Lock()
+	lastCheck := hm.lastCheck
+	if lastCheck.Sub(now) <= clockJumpThreshold {
+		hm.mu.Unlock()
+		return
+	}
+	hm.lastCheck = now
+	hm.mu.Unlock()
+
+	slog.Warn("Last weekly check is in the future, the clock was set back; counting it as now", "lastCheck", lastCheck, "now", now)
+	if err := hm.saveState(); err != nil {
+		slog.Error("Failed to save last check time", "err", err)
+	}
+}
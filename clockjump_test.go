@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClockWatchJump(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	watch := newClockWatch(clock)
+
+	if jump := watch.jump(); jump.Abs() > clockJumpThreshold {
+		t.Errorf("Expected no jump for a clock standing still, got %v", jump)
+	}
+	clock.Advance(2 * time.Hour)
+	if jump := watch.jump(); jump < 2*time.Hour-time.Second || jump > 2*time.Hour {
+		t.Errorf("Expected a jump of about 2h, got %v", jump)
+	}
+	clock.Advance(-3 * time.Hour)
+	if jump := watch.jump(); jump > -3*time.Hour+time.Second || jump < -3*time.Hour-time.Second {
+		t.Errorf("Expected a jump of about -3h, got %v", jump)
+	}
+}
+
+func TestClockWatchRealClock(t *testing.T) {
+	watch := newClockWatch(realClock{})
+	time.Sleep(10 * time.Millisecond)
+	if jump := watch.jump(); jump.Abs() > time.Second {
+		t.Errorf("Expected no jump on the real clock, got %v", jump)
+	}
+}
+
+func TestWeeklyCheckDelayBounded(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Clock = &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager.saveLastCheckTime(context.Background())
+
+	if delay := manager.weeklyCheckDelay(); delay != weeklyRecheckInterval {
+		t.Errorf("Expected the delay to be capped at %v, got %v", weeklyRecheckInterval, delay)
+	}
+}
+
+func TestClampLastCheckAfterClockSetBack(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.saveLastCheckTime(context.Background())
+
+	// The clock is set back by a year, leaving the recorded check in the future.
+	clock.Advance(-365 * 24 * time.Hour)
+	manager.clampLastCheck()
+	lastCheck, err := manager.readLastCheckTime()
+	if err != nil {
+		t.Fatalf("Expected a last check time: %v", err)
+	}
+	if !lastCheck.Equal(clock.Now()) {
+		t.Errorf("Expected the last check to be clamped to %v, got %v", clock.Now(), lastCheck)
+	}
+	want := time.Duration(manager.currentConfig().WeeklyCheckInterval) * time.Hour
+	if d := manager.nextWeeklyCheckDuration(); d != want {
+		t.Errorf("Expected the next check one weekly interval from now, got %v", d)
+	}
+
+	restarted := newTestManager(t)
+	restarted.StateFile = manager.StateFile
+	restarted.Clock = clock
+	restarted.restoreState()
+	if lastCheck, _ := restarted.readLastCheckTime(); !lastCheck.Equal(clock.Now()) {
+		t.Errorf("Expected the clamped last check to be persisted, got %v", lastCheck)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// HeatingController switches the heating. The Shelly relay is the default;
+// other backends can be set in HeatingManager.HeatingController.
+type HeatingController interface {
+	On(ctx context.Context) error
+	Off(ctx context.Context) error
+}
+
+// shellyController is the HeatingController of a Shelly relay switched through its on and off URLs.
+type shellyController struct {
+	hm     *HeatingManager
+	onURL  string
+	offURL string
+}
+
+// On switches the relay on. With a configured status URL it only succeeds once the relay reports on.
+func (c shellyController) On(ctx context.Context) error {
+	if err := c.hm.switchRelay(ctx, c.onURL, true); err != nil {
+		return fmt.Errorf("failed to turn on Shelly: %w", err)
+	}
+	if statusURL := c.hm.currentConfig().ShellyStatusURL; statusURL != "" {
+		if err := c.hm.confirmRelayOn(ctx, statusURL); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "Shelly turned on", "event", eventHeatingOn)
+	return nil
+}
+
+// Off switches the relay off.
+func (c shellyController) Off(ctx context.Context) error {
+	if err := c.hm.switchRelay(ctx, c.offURL, false); err != nil {
+		return fmt.Errorf("failed to turn off Shelly: %w", err)
+	}
+	slog.InfoContext(ctx, "Shelly turned off", "event", eventHeatingOff)
+	return nil
+}
+
+// String describes the relay by its switch URLs.
+func (c shellyController) String() string {
+	return fmt.Sprintf("Shelly (on %s, off %s)", c.onURL, c.offURL)
+}
+
+// heatingController returns HeatingController if set, otherwise the Shelly relay at the given URLs.
+func (hm *HeatingManager) heatingController(shellyHeatingOnURL, shellyHeatingOffURL string) HeatingController {
+	if hm.HeatingController != nil {
+		return hm.HeatingController
+	}
+	return shellyController{hm: hm, onURL: shellyHeatingOnURL, offURL: shellyHeatingOffURL}
+}
+
+// controllerName describes a heating controller for logging, using its String method if it has one.
+func controllerName(controller HeatingController) string {
+	if stringer, ok := controller.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", controller)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// stubController is a HeatingController counting its calls.
+// On fails with onErr, only for the first onFailures calls if that is set.
+type stubController struct {
+	onCalls     atomic.Int32
+	offCalls    atomic.Int32
+	onErr       error
+	onFailures  int32
+	offErr      error
+	offFailures int32
+}
+
+func (c *stubController) On(ctx context.Context) error {
+	if calls := c.onCalls.Add(1); c.onFailures > 0 && calls > c.onFailures {
+		return nil
+	}
+	return c.onErr
+}
+
+func (c *stubController) Off(ctx context.Context) error {
+	if calls := c.offCalls.Add(1); c.offFailures > 0 && calls > c.offFailures {
+		return nil
+	}
+	return c.offErr
+}
+
+func TestWeeklyCheckCustomController(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HeatingDurationMinutes = 0
+	controller := &stubController{}
+	manager.HeatingController = controller
+
+	config := manager.currentConfig()
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.heatingWG.Wait()
+	if controller.onCalls.Load() != 1 {
+		t.Errorf("Expected the controller to be turned on once, got %d", controller.onCalls.Load())
+	}
+	if controller.offCalls.Load() != 1 {
+		t.Errorf("Expected the controller to be turned off once, got %d", controller.offCalls.Load())
+	}
+	manager.mu.Lock()
+	activations := manager.heatingActivations
+	manager.mu.Unlock()
+	if activations != 1 {
+		t.Errorf("Expected 1 heating activation, got %d", activations)
+	}
+}
+
+func TestWeeklyCheckCustomControllerFails(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+	manager.HeatingController = &stubController{onErr: errors.New("plug offline")}
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController("", ""), false); err == nil {
+		t.Fatal("Expected weeklyCheck to fail when the controller cannot turn on")
+	}
+	if _, err := manager.readLastCheckTime(); err == nil {
+		t.Error("Expected no last check time after a failed check")
+	}
+}
+
+func TestSwitchHeatingDryRunSkipsController(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.DryRun = true
+	controller := &stubController{}
+
+	if err := manager.switchHeatingOn(context.Background(), controller); err != nil {
+		t.Fatalf("switchHeatingOn returned an error: %v", err)
+	}
+	if err := manager.switchHeatingOff(context.Background(), controller); err != nil {
+		t.Fatalf("switchHeatingOff returned an error: %v", err)
+	}
+	if controller.onCalls.Load() != 0 || controller.offCalls.Load() != 0 {
+		t.Errorf("Expected no controller calls in dry-run mode, got %d on and %d off", controller.onCalls.Load(), controller.offCalls.Load())
+	}
+}
+
+func TestControllerName(t *testing.T) {
+	if got := controllerName(shellyController{onURL: "http://shelly/on", offURL: "http://shelly/off"}); got != "Shelly (on http://shelly/on, off http://shelly/off)" {
+		t.Errorf("Unexpected Shelly controller name %q", got)
+	}
+	if got := controllerName(&stubController{}); got != "*main.stubController" {
+		t.Errorf("Expected the type name, got %q", got)
+	}
+}
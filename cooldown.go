@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Cooldown describes how the tank cooled since the temperature last dropped below the threshold.
+// Temperatures are in Celsius.
+type Cooldown struct {
+	Since               time.Time `json:"since"`                       // Time the temperature dropped below the threshold.
+	StartTemperature    float64   `json:"startTemperature"`            // First reading below the threshold.
+	HoursSinceHeating   float64   `json:"hoursSinceHeating,omitempty"` // Hours from the last heating run to the threshold crossing.
+	CoolingRate         float64   `json:"coolingRatePerHour"`          // Temperature drop per hour since the crossing.
+	HoursUntilSafetyRun float64   `json:"hoursUntilSafetyRun"`         // Hours until the tank will have been below the threshold for a full weekly interval.
+}
+
+// trackCooldown records a reading in Celsius compared against the threshold in Celsius.
+// A crossing from above to below the threshold starts a new cooldown; later readings below it update the cooling rate.
+func (hm *HeatingManager) trackCooldown(ctx context.Context, readTime time.Time, celsius, thresholdCelsius float64) {
+	weeklyInterval := time.Duration(hm.currentConfig().WeeklyCheckInterval) * time.Hour
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if celsius > thresholdCelsius {
+		hm.aboveThreshold = true
+		return
+	}
+	if hm.aboveThreshold {
+		hm.aboveThreshold = false
+		hm.cooldown = &Cooldown{Since: readTime, StartTemperature: celsius}
+		if !hm.lastHeatingRun.IsZero() {
+			hm.cooldown.HoursSinceHeating = readTime.Sub(hm.lastHeatingRun).Hours()
+		}
+		coolingRateGauge.WithLabelValues(hm.zoneName()).Set(0)
+		slog.InfoContext(ctx, "Temperature dropped below the threshold, tracking cooldown", "temperature", celsius, "threshold", thresholdCelsius, "unit", unitCelsius, "hoursSinceHeating", hm.cooldown.HoursSinceHeating, "safetyRunNeededAt", readTime.Add(weeklyInterval))
+		return
+	}
+	if hm.cooldown == nil {
+		return
+	}
+	if elapsed := readTime.Sub(hm.cooldown.Since); elapsed > 0 {
+		hm.cooldown.CoolingRate = (hm.cooldown.StartTemperature - celsius) / elapsed.Hours()
+		slog.DebugContext(ctx, "Tank cooling", "coolingRatePerHour", hm.cooldown.CoolingRate, "unit", unitCelsius, "since", hm.cooldown.Since)
+	}
+	coolingRateGauge.WithLabelValues(hm.zoneName()).Set(hm.cooldown.CoolingRate)
+}
+
+// Cooldown returns the current cooldown, or nil if the temperature has not dropped below the threshold since the start.
+func (hm *HeatingManager) Cooldown() *Cooldown {
+	weeklyInterval := time.Duration(hm.currentConfig().WeeklyCheckInterval) * time.Hour
+	now := hm.Clock.Now()
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if hm.cooldown == nil {
+		return nil
+	}
+	cooldown := *hm.cooldown
+	cooldown.HoursUntilSafetyRun = max(0, cooldown.Since.Add(weeklyInterval).Sub(now).Hours())
+	return &cooldown
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrackCooldown(t *testing.T) {
+	start := time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)
+	manager := newTestManager(t)
+	manager.Clock = &fakeClock{now: start}
+	manager.Config.WeeklyCheckInterval = 168
+	manager.lastHeatingRun = start.Add(-6 * time.Hour)
+
+	manager.trackCooldown(context.Background(), start.Add(-time.Hour), 50, 55)
+	if manager.Cooldown() != nil {
+		t.Fatal("Expected no cooldown before the threshold was exceeded")
+	}
+
+	manager.trackCooldown(context.Background(), start.Add(-30*time.Minute), 60, 55)
+	manager.trackCooldown(context.Background(), start, 54, 55)
+	cooldown := manager.Cooldown()
+	if cooldown == nil {
+		t.Fatal("Expected a cooldown after the threshold crossing")
+	}
+	if !cooldown.Since.Equal(start) || cooldown.HoursSinceHeating != 6 {
+		t.Errorf("Expected a cooldown since %v, 6 hours after heating, got %+v", start, cooldown)
+	}
+	if cooldown.HoursUntilSafetyRun != 168 {
+		t.Errorf("Expected the safety run to be needed in 168 hours, got %v", cooldown.HoursUntilSafetyRun)
+	}
+
+	manager.trackCooldown(context.Background(), start.Add(4*time.Hour), 52, 55)
+	manager.Clock = &fakeClock{now: start.Add(4 * time.Hour)}
+	cooldown = manager.Cooldown()
+	if math.Abs(cooldown.CoolingRate-0.5) > 1e-9 {
+		t.Errorf("Expected a cooling rate of 0.5 per hour, got %v", cooldown.CoolingRate)
+	}
+	if cooldown.HoursUntilSafetyRun != 164 {
+		t.Errorf("Expected the safety run to be needed in 164 hours, got %v", cooldown.HoursUntilSafetyRun)
+	}
+}
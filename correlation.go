@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+)
+
+// correlationIDAttr is the log attribute holding the correlation ID of a cycle.
+const correlationIDAttr = "correlationID"
+
+// correlationIDKey is the context key of the correlation ID.
+type correlationIDKey struct{}
+
+// withCorrelationID returns a context carrying a new short correlation ID for a temperature check, weekly
+// check or PV cycle. A context that already carries one is returned as is, so a temperature read during
+// a weekly check logs with the ID of the weekly check.
+func withCorrelationID(ctx context.Context) context.Context {
+	if _, ok := correlationID(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationIDKey{}, fmt.Sprintf("%08x", rand.Uint32()))
+}
+
+// correlationID returns the correlation ID carried by ctx, if any.
+func correlationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDKey{}).(string)
+	return id, ok
+}
+
+// detach returns a context for work that outlives the caller's context, such as a heating cycle,
+// but keeps its correlation ID.
+func detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// correlationHandler adds the correlation ID of the logging context to every record logged with one.
+type correlationHandler struct {
+	slog.Handler
+}
+
+// Handle adds the correlation ID, if any, and passes the record on.
+func (h correlationHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id, ok := correlationID(ctx); ok {
+		record.AddAttrs(slog.String(correlationIDAttr, id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a correlationHandler wrapping the handler with the given attributes.
+func (h correlationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return correlationHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a correlationHandler wrapping the handler with the given group.
+func (h correlationHandler) WithGroup(name string) slog.Handler {
+	return correlationHandler{h.Handler.WithGroup(name)}
+}
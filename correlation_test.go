@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithCorrelationID(t *testing.T) {
+	ctx := withCorrelationID(context.Background())
+	id, ok := correlationID(ctx)
+	if !ok || len(id) != 8 {
+		t.Fatalf("Expected an 8 character correlation ID, got %q", id)
+	}
+	if nested, _ := correlationID(withCorrelationID(ctx)); nested != id {
+		t.Errorf("Expected a nested cycle to keep the ID %q, got %q", id, nested)
+	}
+	if other, _ := correlationID(withCorrelationID(context.Background())); other == id {
+		t.Errorf("Expected a new cycle to get a new ID, got %q again", other)
+	}
+	if _, ok := correlationID(context.Background()); ok {
+		t.Error("Expected no correlation ID outside a cycle")
+	}
+}
+
+func TestNewLoggerCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "info", time.UTC).With("zone", "tank2")
+	ctx := withCorrelationID(context.Background())
+	id, _ := correlationID(ctx)
+
+	logger.InfoContext(ctx, "Temperature is OK")
+	logger.Info("Starting heating manager")
+	records := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 log records, got %q", buf.String())
+	}
+	var inCycle, outside map[string]any
+	if err := json.Unmarshal([]byte(records[0]), &inCycle); err != nil {
+		t.Fatalf("Expected a JSON log record, got %q: %v", records[0], err)
+	}
+	if err := json.Unmarshal([]byte(records[1]), &outside); err != nil {
+		t.Fatalf("Expected a JSON log record, got %q: %v", records[1], err)
+	}
+	if inCycle[correlationIDAttr] != id || inCycle["zone"] != "tank2" {
+		t.Errorf("Expected the correlation ID %q and the zone, got %v", id, inCycle)
+	}
+	if _, ok := outside[correlationIDAttr]; ok {
+		t.Errorf("Expected no correlation ID outside a cycle, got %v", outside)
+	}
+}
+
+// captureLogs routes the default logger into a buffer at debug level until the test ends.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(newLogger(&buf, "debug", time.UTC))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// correlationIDs returns the correlation ID of the first log record with each of the given messages.
+func correlationIDs(t *testing.T, logs *bytes.Buffer, messages ...string) map[string]string {
+	t.Helper()
+	ids := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected a JSON log record, got %q: %v", line, err)
+		}
+		msg, _ := record["msg"].(string)
+		if _, seen := ids[msg]; seen {
+			continue
+		}
+		id, _ := record[correlationIDAttr].(string)
+		ids[msg] = id
+	}
+	for _, msg := range messages {
+		if ids[msg] == "" {
+			t.Errorf("Expected %q to be logged with a correlation ID, got %q", msg, logs.String())
+		}
+	}
+	return ids
+}
+
+func TestNotifyFailureCorrelationID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = ts.URL
+	logs := captureLogs(t)
+	ctx := withCorrelationID(context.Background())
+	id, _ := correlationID(ctx)
+
+	manager.notify(ctx, eventHeatingOn, reasonWeeklyLegionella)
+	if ids := correlationIDs(t, logs, "Failed to send webhook notification"); ids["Failed to send webhook notification"] != id {
+		t.Errorf("Expected the failed delivery to be logged with the cycle's correlation ID %q, got %v", id, ids)
+	}
+}
+
+func TestCheckCycleCorrelationID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/temp" {
+			_, _ = w.Write([]byte(`{"id":0,"tC":48.5}`))
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+	manager.Config.HeatingDurationMinutes = 0
+	logs := captureLogs(t)
+
+	if err := manager.checkTemperature(context.Background(), []string{ts.URL + "/temp"}); err != nil {
+		t.Fatalf("checkTemperature returned an error: %v", err)
+	}
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.heatingWG.Wait()
+
+	ids := correlationIDs(t, logs, "Sensor temperature", "Temperature is OK", "Shelly turned on", "Shelly turned off")
+	if ids["Sensor temperature"] != ids["Temperature is OK"] {
+		t.Errorf("Expected the read and the decision of a check to share a correlation ID, got %v", ids)
+	}
+	if ids["Shelly turned on"] != ids["Shelly turned off"] {
+		t.Errorf("Expected the heating cycle to keep the weekly check's correlation ID, got %v", ids)
+	}
+	if ids["Temperature is OK"] == ids["Shelly turned on"] {
+		t.Errorf("Expected the temperature and weekly checks to get different correlation IDs, got %v", ids)
+	}
+}
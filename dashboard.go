@@ -0,0 +1,73 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// dashboardRefresh is the interval after which the dashboard page reloads itself.
+const dashboardRefresh = 30 * time.Second
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// dashboardData is the data rendered by the dashboard template.
+type dashboardData struct {
+	Zones          []dashboardZone
+	RefreshSeconds int
+	BasePath       string
+}
+
+// dashboardZone is the status of one zone on the dashboard; Name is empty without zones.
+type dashboardZone struct {
+	Name                string
+	Temperature         float64
+	Precision           int
+	Unit                string
+	LastReadTime        time.Time
+	Threshold           float64
+	TemperatureExceeded bool
+	LastCheck           time.Time
+	NextCheck           time.Time
+}
+
+// handleDashboard renders an HTML page with the current status of every zone.
+func (hm *HeatingManager) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	data := dashboardData{
+		RefreshSeconds: int(dashboardRefresh.Seconds()),
+		BasePath:       hm.currentConfig().HTTPBasePath,
+	}
+	for _, zm := range hm.zoneManagers() {
+		data.Zones = append(data.Zones, zm.dashboardZone())
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		slog.Warn("Failed to render dashboard", "err", err)
+	}
+}
+
+// dashboardZone returns the dashboard status of the zone heated by the manager.
+func (hm *HeatingManager) dashboardZone() dashboardZone {
+	config := hm.currentConfig()
+	temperature, readTime := hm.CurrentTemperature()
+	zone := dashboardZone{
+		Name:                hm.Zone,
+		Temperature:         temperature,
+		Precision:           config.precision(),
+		Unit:                config.TemperatureUnit,
+		LastReadTime:        readTime,
+		Threshold:           config.TemperatureThreshold,
+		TemperatureExceeded: hm.isTemperatureExceeded(),
+		NextCheck:           hm.NextWeeklyCheck(),
+	}
+	if lastCheck, err := hm.readLastCheckTime(); err == nil {
+		zone.LastCheck = lastCheck
+	}
+	return zone
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// diagReading is the raw result of reading one temperature sensor, returned by GET /diag/shelly-temp.
+type diagReading struct {
+	Zone        string      `json:"zone"`
+	URL         string      `json:"url"`
+	StatusCode  int         `json:"statusCode,omitempty"`
+	Headers     http.Header `json:"headers,omitempty"`
+	Body        string      `json:"body"`
+	Truncated   bool        `json:"truncated,omitempty"` // Set if the body exceeded maxResponseBytes and was cut off there.
+	Temperature *float64    `json:"temperature,omitempty"`
+	Unit        string      `json:"unit"`
+	Error       string      `json:"error,omitempty"` // Request or parse error, empty if the temperature was parsed.
+}
+
+// diagResponse is the JSON body returned by GET /diag/shelly-temp.
+type diagResponse struct {
+	Sensors []diagReading `json:"sensors"`
+}
+
+// handleDiagShellyTemp reads every configured temperature sensor of every zone once, without retries,
+// and returns the raw responses together with the parsed temperatures or parse errors.
+func (hm *HeatingManager) handleDiagShellyTemp(w http.ResponseWriter, r *http.Request) {
+	response := diagResponse{Sensors: []diagReading{}}
+	for _, zm := range hm.zoneManagers() {
+		config := zm.currentConfig()
+		sensors := config.ShellyURLs
+		if config.ShellyTempFallbackURL != "" {
+			sensors = append(sensors[:len(sensors):len(sensors)], config.ShellyTempFallbackURL)
+		}
+		for _, sensorURL := range sensors {
+			reading := zm.diagRead(r.Context(), sensorURL)
+			reading.Zone = zm.zoneName()
+			response.Sensors = append(response.Sensors, reading)
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// diagRead reads a temperature sensor once and reports the raw response and how it was parsed.
+func (hm *HeatingManager) diagRead(ctx context.Context, sensorURL string) diagReading {
+	config := hm.currentConfig()
+	reading := diagReading{URL: sensorURL, Unit: config.TemperatureUnit}
+	resp, err := hm.shellyGet(ctx, sensorURL)
+	if err != nil {
+		reading.Error = err.Error()
+		return reading
+	}
+	defer resp.Body.Close()
+	reading.StatusCode = resp.StatusCode
+	reading.Headers = resp.Header
+
+	maxBytes := int64(config.MaxResponseBytes)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if int64(len(body)) > maxBytes {
+		body = body[:maxBytes]
+		reading.Truncated = true
+	}
+	reading.Body = string(body)
+	switch {
+	case err != nil:
+		reading.Error = "failed to read response body: " + err.Error()
+	case resp.StatusCode != http.StatusOK:
+		reading.Error = (&StatusError{StatusCode: resp.StatusCode}).Error()
+	case reading.Truncated:
+		reading.Error = "response body larger than maxResponseBytes"
+	default:
+		temperature, err := hm.parseTemperature(body)
+		if err != nil {
+			reading.Error = err.Error()
+		} else {
+			reading.Temperature = &temperature
+		}
+	}
+	return reading
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleDiagShellyTemp(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":100,"tC":54.25,"tF":129.65}`))
+		case "/html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>login</html>"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("sensor error"))
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{ts.URL + "/good", ts.URL + "/html"}
+	manager.Config.ShellyTempFallbackURL = ts.URL + "/broken"
+	manager.Config.APIToken = "secret"
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/diag/shelly-temp", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/diag/shelly-temp", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var response diagResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Sensors) != 3 {
+		t.Fatalf("Expected three sensors, got %d", len(response.Sensors))
+	}
+
+	good, html, broken := response.Sensors[0], response.Sensors[1], response.Sensors[2]
+	if good.Temperature == nil || *good.Temperature != 54.25 || good.Error != "" || good.Zone != defaultZoneName {
+		t.Errorf("Expected the parsed temperature 54.25, got %+v", good)
+	}
+	if good.Headers.Get("Content-Type") != "application/json" || good.Body != `{"id":100,"tC":54.25,"tF":129.65}` {
+		t.Errorf("Expected the raw headers and body, got %+v", good)
+	}
+	if html.Temperature != nil || html.Error == "" || html.Body != "<html>login</html>" || html.StatusCode != http.StatusOK {
+		t.Errorf("Expected a parse error with the raw body, got %+v", html)
+	}
+	if broken.StatusCode != http.StatusInternalServerError || broken.Body != "sensor error" || broken.Error != "status code 500" {
+		t.Errorf("Expected the failed response of the fallback sensor, got %+v", broken)
+	}
+}
+
+func TestDiagReadTruncated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.MaxResponseBytes = 4
+	reading := manager.diagRead(context.Background(), ts.URL)
+	if !reading.Truncated || reading.Body != "0123" || reading.Temperature != nil {
+		t.Errorf("Expected the body truncated to 4 bytes without a temperature, got %+v", reading)
+	}
+
+	unreachable := manager.diagRead(context.Background(), "http://127.0.0.1:1/temperature")
+	if unreachable.Error == "" || unreachable.StatusCode != 0 {
+		t.Errorf("Expected a request error, got %+v", unreachable)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+)
+
+// digestChallenge holds the parameters of a WWW-Authenticate Digest challenge.
+type digestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	QOP       string
+	Algorithm string
+}
+
+// parseDigestChallenge parses a WWW-Authenticate header value of the Digest scheme.
+func parseDigestChallenge(header string) (digestChallenge, error) {
+	var challenge digestChallenge
+	scheme, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return challenge, fmt.Errorf("unsupported authentication challenge %q", header)
+	}
+
+	for _, param := range splitDigestParams(params) {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "realm":
+			challenge.Realm = value
+		case "nonce":
+			challenge.Nonce = value
+		case "opaque":
+			challenge.Opaque = value
+		case "qop":
+			// Prefer "auth" if the server offers several options.
+			for _, qop := range strings.Split(value, ",") {
+				if strings.TrimSpace(qop) == "auth" {
+					challenge.QOP = "auth"
+				}
+			}
+		case "algorithm":
+			challenge.Algorithm = value
+		}
+	}
+
+	if challenge.Nonce == "" {
+		return challenge, fmt.Errorf("digest challenge without nonce")
+	}
+	return challenge, nil
+}
+
+// splitDigestParams splits comma-separated challenge parameters, ignoring commas in quoted values.
+func splitDigestParams(params string) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		quoted  bool
+	)
+	for _, r := range params {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ',' && !quoted:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// authorization computes the Authorization header answering the challenge for a request.
+func (c digestChallenge) authorization(method, uri, username, password, cnonce string) (string, error) {
+	var newHash func() hash.Hash
+	switch strings.ToUpper(c.Algorithm) {
+	case "", "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", c.Algorithm)
+	}
+	digest := func(s string) string {
+		h := newHash()
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	const nc = "00000001"
+	ha1 := digest(username + ":" + c.Realm + ":" + password)
+	ha2 := digest(method + ":" + uri)
+
+	var response string
+	if c.QOP == "auth" {
+		response = digest(ha1 + ":" + c.Nonce + ":" + nc + ":" + cnonce + ":" + c.QOP + ":" + ha2)
+	} else {
+		response = digest(ha1 + ":" + c.Nonce + ":" + ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		username, c.Realm, c.Nonce, uri, response)
+	if c.Algorithm != "" {
+		header += ", algorithm=" + c.Algorithm
+	}
+	if c.QOP == "auth" {
+		header += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s"`, nc, cnonce)
+	}
+	if c.Opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, c.Opaque)
+	}
+	return header, nil
+}
+
+// newCnonce returns a random client nonce.
+func newCnonce() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// shellyGet issues a GET request to a Shelly device, answering a digest authentication
+// challenge with the configured credentials. Without credentials it behaves like httpGet.
+func (hm *HeatingManager) shellyGet(ctx context.Context, url string) (*http.Response, error) {
+	config := hm.currentConfig()
+	resp, err := hm.httpGet(ctx, url)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || config.ShellyUsername == "" {
+		return resp, err
+	}
+
+	header := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+	challenge, err := parseDigestChallenge(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	cnonce, err := newCnonce()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cnonce: %v", err)
+	}
+	authorization, err := challenge.authorization(req.Method, req.URL.RequestURI(), config.ShellyUsername, config.ShellyPassword, cnonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %v", err)
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err = hm.do(req)
+	if err != nil {
+		return nil, unreachable(err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	// Example from RFC 2617, section 3.5.
+	challenge, err := parseDigestChallenge(`Digest realm="testrealm@host.com", qop="auth,auth-int", nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093", opaque="5ccc069c403ebaf9f0171e9517f40e41"`)
+	if err != nil {
+		t.Fatalf("parseDigestChallenge returned an error: %v", err)
+	}
+	if challenge.QOP != "auth" || challenge.Realm != "testrealm@host.com" {
+		t.Errorf("Unexpected challenge: %+v", challenge)
+	}
+
+	header, err := challenge.authorization("GET", "/dir/index.html", "Mufasa", "Circle Of Life", "0a4f113b")
+	if err != nil {
+		t.Fatalf("authorization returned an error: %v", err)
+	}
+	if !strings.Contains(header, `response="6629fae49393a05397450978507c4ef1"`) {
+		t.Errorf("Unexpected digest response in %s", header)
+	}
+}
+
+func TestShellyGetDigestAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), `username="admin"`) {
+			w.Header().Set("WWW-Authenticate", `Digest qop="auth", realm="shellyplus1-a8032ab12345", nonce="60dc59c6", algorithm=SHA-256`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+
+	resp, err := manager.shellyGet(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("shellyGet returned an error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", resp.StatusCode)
+	}
+
+	manager.Config.ShellyUsername = "admin"
+	manager.Config.ShellyPassword = "secret"
+	resp, err = manager.shellyGet(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("shellyGet returned an error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 with credentials, got %d", resp.StatusCode)
+	}
+}
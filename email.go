@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emailEvents are the notification events that are also sent by email.
+var emailEvents = map[string]bool{
+	eventHeatingOn:        true,
+	eventHeatingFailed:    true,
+	eventHeatingOffFailed: true,
+	eventReadFailures:     true,
+	eventReadRecovered:    true,
+	eventServiceStarted:   true,
+	eventServiceStopped:   true,
+}
+
+// smtpSendMail delivers a message via SMTP; replaced in tests.
+var smtpSendMail = sendMailStartTLS
+
+// sendEmail sends a notification as a plain text email to the configured recipient.
+func (hm *HeatingManager) sendEmail(notification Notification) error {
+	config := hm.currentConfig()
+	var auth smtp.Auth
+	if config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(config.SMTPHost, strconv.Itoa(config.SMTPPort))
+	msg := emailMessage(config.EmailFrom, config.EmailTo, "Heating manager: "+notification.Event, notification.Message(), notification.Time)
+	timeout := time.Duration(config.HTTPTimeout) * time.Second
+	if err := smtpSendMail(addr, auth, config.EmailFrom, []string{config.EmailTo}, msg, timeout); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+	return nil
+}
+
+// emailMessage builds an RFC 5322 plain text message dated date.
+func emailMessage(from, to, subject, body string, date time.Time) []byte {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
+
+// sendMailStartTLS works like smtp.SendMail but refuses to send without STARTTLS.
+// Connecting and the whole conversation with the server must finish within timeout,
+// so a hung server cannot block the notification.
+func sendMailStartTLS(addr string, auth smtp.Auth, from string, to []string, msg []byte, timeout time.Duration) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); !ok {
+		return fmt.Errorf("server %s does not support STARTTLS", host)
+	}
+	if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+		return err
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
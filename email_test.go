@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendEmail(t *testing.T) {
+	var (
+		sentAddr string
+		sentTo   []string
+		sentMsg  string
+		sent     int
+	)
+	oldSendMail := smtpSendMail
+	smtpSendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte, timeout time.Duration) error {
+		sentAddr, sentTo, sentMsg = addr, to, string(msg)
+		sent++
+		return nil
+	}
+	defer func() { smtpSendMail = oldSendMail }()
+
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.SMTPHost = "mail.example.com"
+	manager.Config.SMTPPort = 587
+	manager.Config.EmailFrom = "heating@example.com"
+	manager.Config.EmailTo = "me@example.com"
+
+	manager.notify(context.Background(), eventHeatingOn, reasonWeeklyLegionella)
+	if sent != 1 {
+		t.Fatalf("Expected 1 email, got %d", sent)
+	}
+	if sentAddr != "mail.example.com:587" {
+		t.Errorf("Expected address mail.example.com:587, got %s", sentAddr)
+	}
+	if len(sentTo) != 1 || sentTo[0] != "me@example.com" {
+		t.Errorf("Unexpected recipients %v", sentTo)
+	}
+	if !strings.Contains(sentMsg, "Subject: Heating manager: heating_on") {
+		t.Errorf("Expected subject in message, got %q", sentMsg)
+	}
+	if !strings.Contains(sentMsg, "Date: "+clock.Now().Format(time.RFC1123Z)) {
+		t.Errorf("Expected the message dated by the manager's clock, got %q", sentMsg)
+	}
+
+	manager.notify(context.Background(), eventHeatingSkipped, reasonThresholdExceeded)
+	if sent != 1 {
+		t.Errorf("Expected no email for a skipped heating run, got %d emails", sent)
+	}
+}
+
+func TestSendMailStartTLSTimeout(t *testing.T) {
+	// The server accepts the connection but never sends its greeting.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	start := time.Now()
+	err = sendMailStartTLS(listener.Addr().String(), nil, "heating@example.com", []string{"me@example.com"}, []byte("test"), 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("Expected an error from a server that never responds")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected sending to give up after the timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envOverride sets a config field from the value of an environment variable.
+type envOverride struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+// envOverrides are the environment variables overlaid on the config file, taking precedence over it.
+var envOverrides = []envOverride{
+	{"PV_SHELLY_TEMP_URL", func(c *Config, v string) error { c.ShellyURL, c.ShellyURLs = v, nil; return nil }},
+	{"PV_SHELLY_TEMP_URLS", func(c *Config, v string) error { c.ShellyURLs = strings.Split(v, ","); return nil }},
+	{"PV_SHELLY_TEMP_FALLBACK_URL", envString(func(c *Config) *string { return &c.ShellyTempFallbackURL })},
+	{"PV_SHELLY_HEATING_ON_URL", envString(func(c *Config) *string { return &c.ShellyHeatingOnURL })},
+	{"PV_SHELLY_HEATING_OFF_URL", envString(func(c *Config) *string { return &c.ShellyHeatingOffURL })},
+	{"PV_SHELLY_USERNAME", envString(func(c *Config) *string { return &c.ShellyUsername })},
+	{"PV_SHELLY_PASSWORD", envString(func(c *Config) *string { return &c.ShellyPassword })},
+	{"PV_TEMP_THRESHOLD", envFloat(func(c *Config) *float64 { return &c.TemperatureThreshold })},
+	{"PV_TEMP_TURN_OFF", envFloat(func(c *Config) *float64 { return &c.TemperatureTurnOff })},
+	{"PV_MAX_SAFE_TEMPERATURE", envFloat(func(c *Config) *float64 { return &c.MaxSafeTemperature })},
+	{"PV_CHECK_INTERVAL", envInt(func(c *Config) *int { return &c.CheckInterval })},
+	{"PV_WEEKLY_CHECK_INTERVAL", envInt(func(c *Config) *int { return &c.WeeklyCheckInterval })},
+	{"PV_HEATING_DURATION_MINUTES", envInt(func(c *Config) *int { return &c.HeatingDurationMinutes })},
+	{"PV_LOG_LEVEL", envString(func(c *Config) *string { return &c.LogLevel })},
+	{"PV_DRY_RUN", envBool(func(c *Config) *bool { return &c.DryRun })},
+	{"PV_API_TOKEN", envString(func(c *Config) *string { return &c.APIToken })},
+	{"PV_TELEGRAM_BOT_TOKEN", envString(func(c *Config) *string { return &c.TelegramBotToken })},
+	{"PV_SMTP_PASSWORD", envString(func(c *Config) *string { return &c.SMTPPassword })},
+	{"PV_MQTT_PASSWORD", envString(func(c *Config) *string { return &c.MQTTPassword })},
+	{"PV_INFLUX_TOKEN", envString(func(c *Config) *string { return &c.InfluxToken })},
+	{"PV_HTTP_AUTH_USER", envString(func(c *Config) *string { return &c.HTTPAuthUser })},
+	{"PV_HTTP_AUTH_PASSWORD", envString(func(c *Config) *string { return &c.HTTPAuthPassword })},
+}
+
+// envString returns a setter for a string config field.
+func envString(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+// envInt returns a setter parsing an integer config field.
+func envInt(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// envFloat returns a setter parsing a number config field.
+func envFloat(field func(c *Config) *float64) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", value)
+		}
+		*field(c) = f
+		return nil
+	}
+}
+
+// envBool returns a setter parsing a boolean config field.
+func envBool(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// applyEnv overlays the set environment variables on the config.
+// It reports whether any variable was set and returns an error naming a malformed one.
+func (c *Config) applyEnv() (bool, error) {
+	applied := false
+	for _, override := range envOverrides {
+		value, ok := os.LookupEnv(override.name)
+		if !ok {
+			continue
+		}
+		if err := override.set(c, value); err != nil {
+			return applied, fmt.Errorf("invalid environment variable %s: %v", override.name, err)
+		}
+		applied = true
+	}
+	return applied, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	t.Setenv("PV_TEMP_THRESHOLD", "58.5")
+	t.Setenv("PV_CHECK_INTERVAL", "7")
+	t.Setenv("PV_SHELLY_TEMP_URL", "http://env-shelly/temp")
+	t.Setenv("PV_DRY_RUN", "true")
+
+	config, err := loadConfig("config.json")
+	if err != nil {
+		t.Fatalf("loadConfig returned an error: %v", err)
+	}
+	if config.TemperatureThreshold != 58.5 || config.CheckInterval != 7 || !config.DryRun {
+		t.Errorf("Expected the environment to take precedence, got threshold %v, interval %d, dry run %v", config.TemperatureThreshold, config.CheckInterval, config.DryRun)
+	}
+	if len(config.ShellyURLs) != 1 || config.ShellyURLs[0] != "http://env-shelly/temp" {
+		t.Errorf("Expected the temperature URL from the environment, got %v", config.ShellyURLs)
+	}
+}
+
+func TestLoadConfigEnvMalformed(t *testing.T) {
+	for name, value := range map[string]string{"PV_CHECK_INTERVAL": "five", "PV_TEMP_THRESHOLD": "hot", "PV_DRY_RUN": "maybe"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := loadConfig("config.json"); err == nil {
+				t.Errorf("Expected an error for %s=%s", name, value)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvWithoutFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "config.json")
+	if _, err := loadConfig(missing); err == nil {
+		t.Error("Expected an error for a missing config file without environment variables")
+	}
+
+	t.Setenv("PV_SHELLY_TEMP_URL", "http://shelly/temp")
+	t.Setenv("PV_SHELLY_HEATING_ON_URL", "http://shelly/on")
+	t.Setenv("PV_SHELLY_HEATING_OFF_URL", "http://shelly/off")
+	t.Setenv("PV_TEMP_THRESHOLD", "55")
+	t.Setenv("PV_TEMP_TURN_OFF", "60")
+	t.Setenv("PV_CHECK_INTERVAL", "5")
+	t.Setenv("PV_WEEKLY_CHECK_INTERVAL", "168")
+	if _, err := loadConfig(missing); err != nil {
+		t.Errorf("Expected a config from the environment alone, got %v", err)
+	}
+}
+
+func TestLoadConfigEnvWithoutFileIncomplete(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv("PV_TEMP_THRESHOLD", "55")
+	_, err := loadConfig(missing)
+	if err == nil {
+		t.Fatal("Expected an error for a config missing required values")
+	}
+	if !strings.HasPrefix(err.Error(), "invalid config:") {
+		t.Errorf("Expected the missing values to be reported, got %v", err)
+	}
+}
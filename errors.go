@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error classes of device requests, to be checked with errors.Is.
+var (
+	// ErrDeviceUnreachable is wrapped by errors of requests that got no response.
+	ErrDeviceUnreachable = errors.New("device unreachable")
+	// ErrBadStatus is matched by a StatusError, a response with an unexpected status code.
+	ErrBadStatus = errors.New("bad status")
+	// ErrParse is wrapped by errors of responses that could not be parsed.
+	ErrParse = errors.New("invalid response")
+	// ErrEmptyResponse is wrapped by errors of successful responses without a body, e.g. from a rebooting device.
+	// Unlike ErrParse it is retried.
+	ErrEmptyResponse = errors.New("empty response")
+)
+
+// StatusError is a response with an unexpected status code. It matches ErrBadStatus.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code %d", e.StatusCode)
+}
+
+// Is reports whether target is ErrBadStatus.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrBadStatus
+}
+
+// unreachable wraps a failed request in ErrDeviceUnreachable, keeping the cause, e.g. a context error, matchable.
+func unreachable(err error) error {
+	return fmt.Errorf("%w: %w", ErrDeviceUnreachable, err)
+}
+
+// retryable reports whether a failed request may succeed when repeated.
+// Client errors other than 429 Too Many Requests and unparseable responses are not retried.
+func retryable(err error) bool {
+	if errors.Is(err, ErrParse) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// errorReason returns the notification reason for the class of err, or fallback if it has none.
+func errorReason(err error, fallback string) string {
+	switch {
+	case errors.Is(err, ErrDeviceUnreachable):
+		return reasonDeviceUnreachable
+	case errors.Is(err, ErrBadStatus):
+		return reasonBadStatus
+	case errors.Is(err, ErrParse), errors.Is(err, ErrEmptyResponse):
+		return reasonInvalidResponse
+	}
+	return fallback
+}
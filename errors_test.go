@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetTemperatureErrorClasses(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.MaxRetries = 0
+
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}}
+	if _, err := manager.getTemperature(context.Background(), "http://shelly/temp"); !errors.Is(err, ErrDeviceUnreachable) {
+		t.Errorf("Expected ErrDeviceUnreachable, got %v", err)
+	}
+
+	manager.HTTPClient = stubResponse(http.StatusServiceUnavailable, "")
+	_, err := manager.getTemperature(context.Background(), "http://shelly/temp")
+	var statusErr *StatusError
+	if !errors.Is(err, ErrBadStatus) || !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a StatusError with status code 503, got %v", err)
+	}
+
+	manager.HTTPClient = stubResponse(http.StatusOK, "<html>")
+	if _, err := manager.getTemperature(context.Background(), "http://shelly/temp"); !errors.Is(err, ErrParse) {
+		t.Errorf("Expected ErrParse, got %v", err)
+	}
+}
+
+func TestGetTemperatureEmptyBodyRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Write([]byte(`{"tC": 55.5, "tF": 131.9}`))
+	}))
+	defer server.Close()
+
+	manager := newTestManager(t)
+	manager.HTTPClient = server.Client()
+	manager.Config.MaxRetries = 2
+	manager.Config.RetryBackoff = 1
+
+	temperature, err := manager.getTemperature(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if temperature != 55.5 || calls.Load() != 2 {
+		t.Errorf("Expected 55.5 after 2 attempts, got %v after %d", temperature, calls.Load())
+	}
+}
+
+func TestGetTemperatureEmptyBody(t *testing.T) {
+	for _, body := range []string{"", " \r\n"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		manager := newTestManager(t)
+		manager.HTTPClient = server.Client()
+		manager.Config.MaxRetries = 0
+
+		_, err := manager.getTemperature(context.Background(), server.URL)
+		server.Close()
+		if !errors.Is(err, ErrEmptyResponse) || errors.Is(err, ErrParse) {
+			t.Errorf("Body %q: expected ErrEmptyResponse, got %v", body, err)
+		}
+		if !retryable(err) {
+			t.Errorf("Body %q: expected an empty response to be retryable", body)
+		}
+	}
+}
+
+func TestUnreachableKeepsCause(t *testing.T) {
+	err := unreachable(context.DeadlineExceeded)
+	if !errors.Is(err, ErrDeviceUnreachable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected both ErrDeviceUnreachable and the cause, got %v", err)
+	}
+}
+
+func TestGetTemperatureClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	manager := newTestManager(t)
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}
+	manager.Config.MaxRetries = 3
+	manager.Config.RetryBackoff = 1
+
+	if _, err := manager.getTemperature(context.Background(), "http://shelly/temp"); !errors.Is(err, ErrBadStatus) {
+		t.Errorf("Expected ErrBadStatus, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected a single attempt for status code 404, got %d", calls.Load())
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unreachable", unreachable(errors.New("connection refused")), true},
+		{"server error", &StatusError{StatusCode: http.StatusBadGateway}, true},
+		{"too many requests", &StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{"client error", &StatusError{StatusCode: http.StatusUnauthorized}, false},
+		{"parse error", ErrParse, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{unreachable(errors.New("timeout")), reasonDeviceUnreachable},
+		{errors.Join(errors.New("sensor 1"), &StatusError{StatusCode: http.StatusInternalServerError}), reasonBadStatus},
+		{ErrParse, reasonInvalidResponse},
+		{fmt.Errorf("failed to get temperature: %w", ErrEmptyResponse), reasonInvalidResponse},
+		{errors.New("no temperature sensors configured"), reasonRepeatedFailures},
+	}
+	for _, tt := range tests {
+		if got := errorReason(tt.err, reasonRepeatedFailures); got != tt.want {
+			t.Errorf("%v: expected reason %q, got %q", tt.err, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthResponse is the JSON body returned by the /healthz endpoint.
+type healthResponse struct {
+	Zone            string    `json:"zone,omitempty"`
+	Status          string    `json:"status"`
+	LastReadTime    time.Time `json:"lastReadTime"`
+	LastTemperature float64   `json:"lastTemperature"`
+	NextWeeklyCheck string    `json:"nextWeeklyCheck"`
+}
+
+// StartHealthServer serves the /healthz endpoint until the context is cancelled.
+// It does nothing if no health port is configured.
+func (hm *HeatingManager) StartHealthServer(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.HealthPort == 0 {
+		return
+	}
+
+	serveHTTP(ctx, fmt.Sprintf(":%d", config.HealthPort), config.HTTPBasePath, hm.healthHandler())
+}
+
+// healthHandler returns the handler for /healthz, behind Basic Auth unless HTTPAuthExcludeHealthz is set.
+func (hm *HeatingManager) healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", hm.handleHealthz)
+	if hm.currentConfig().HTTPAuthExcludeHealthz {
+		return mux
+	}
+	return hm.requireBasicAuth(mux)
+}
+
+// zonesHealthResponse is the JSON body returned by the /healthz endpoint for a config with zones.
+type zonesHealthResponse struct {
+	Status string           `json:"status"`
+	Zones  []healthResponse `json:"zones"`
+}
+
+// handleHealthz reports healthy when the last successful temperature read is recent, see healthMaxAge.
+// With zones, every zone must be healthy.
+func (hm *HeatingManager) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if len(hm.zones) == 0 {
+		response := hm.health()
+		writeJSON(w, healthStatusCode(response.Status), response)
+		return
+	}
+
+	response := zonesHealthResponse{Status: "ok"}
+	for _, zm := range hm.zones {
+		zoneHealth := zm.health()
+		zoneHealth.Zone = zm.Zone
+		if zoneHealth.Status != "ok" {
+			response.Status = zoneHealth.Status
+		}
+		response.Zones = append(response.Zones, zoneHealth)
+	}
+	writeJSON(w, healthStatusCode(response.Status), response)
+}
+
+// health returns the health of the manager's own temperature reads.
+func (hm *HeatingManager) health() healthResponse {
+	temperature, readTime := hm.CurrentTemperature()
+	response := healthResponse{
+		Status:          "ok",
+		LastReadTime:    readTime,
+		LastTemperature: temperature,
+		NextWeeklyCheck: formatNextWeeklyCheck(hm.NextWeeklyCheck()),
+	}
+	now := hm.Clock.Now()
+	if response.LastReadTime.IsZero() || now.Sub(response.LastReadTime) > hm.healthMaxAge(response.LastReadTime, now) {
+		response.Status = "unhealthy"
+	}
+	return response
+}
+
+// healthMaxAge returns how old a read at readTime may be at now while the manager is healthy:
+// two check intervals, plus the time outside the active hours since the read, during which checks are
+// skipped, but at most two inactive check intervals if inactiveCheckInterval keeps checking at night.
+func (hm *HeatingManager) healthMaxAge(readTime, now time.Time) time.Duration {
+	inactive := hm.inactiveTime(readTime, now)
+	if interval := time.Duration(hm.currentConfig().InactiveCheckInterval) * time.Minute; interval > 0 {
+		inactive = min(inactive, 2*interval)
+	}
+	return 2*hm.checkInterval() + inactive
+}
+
+// healthStatusCode returns the HTTP status code of a health status.
+func healthStatusCode(status string) int {
+	if status != "ok" {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	manager := newTestManager(t)
+
+	rec := httptest.NewRecorder()
+	manager.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before any read, got %d", rec.Code)
+	}
+
+	manager.LastReadTime = time.Now()
+	manager.LastTemperature = 48.5
+	rec = httptest.NewRecorder()
+	manager.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 after a recent read, got %d", rec.Code)
+	}
+	var response healthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode health response: %v", err)
+	}
+	if response.NextWeeklyCheck != "pending" {
+		t.Errorf("Expected a pending weekly check, got %q", response.NextWeeklyCheck)
+	}
+
+	manager.LastReadTime = time.Now().Add(-3 * manager.CheckInterval)
+	rec = httptest.NewRecorder()
+	manager.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 after a stale read, got %d", rec.Code)
+	}
+}
+
+func TestHealthActiveHours(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 3, 0, 0, 0, time.Local)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.ActiveHoursStart = 7
+	manager.Config.ActiveHoursEnd = 20
+	manager.LastReadTime = time.Date(2024, 3, 5, 19, 58, 0, 0, time.Local)
+
+	if status := manager.health().Status; status != "ok" {
+		t.Errorf("Expected the last read before the inactive hours to stay healthy at night, got %q", status)
+	}
+	clock.now = time.Date(2024, 3, 6, 7, 5, 0, 0, time.Local)
+	if status := manager.health().Status; status != "ok" {
+		t.Errorf("Expected a read to be due only after the active hours started, got %q", status)
+	}
+	clock.now = time.Date(2024, 3, 6, 7, 30, 0, 0, time.Local)
+	if status := manager.health().Status; status != "unhealthy" {
+		t.Errorf("Expected no read within the active hours to be unhealthy, got %q", status)
+	}
+
+	manager.Config.InactiveCheckInterval = 60
+	manager.LastReadTime = time.Date(2024, 3, 6, 1, 0, 0, 0, time.Local)
+	clock.now = time.Date(2024, 3, 6, 2, 30, 0, 0, time.Local)
+	if status := manager.health().Status; status != "ok" {
+		t.Errorf("Expected a read within two inactive check intervals to be healthy, got %q", status)
+	}
+	clock.now = time.Date(2024, 3, 6, 3, 30, 0, 0, time.Local)
+	if status := manager.health().Status; status != "unhealthy" {
+		t.Errorf("Expected a missed inactive check to be unhealthy, got %q", status)
+	}
+}
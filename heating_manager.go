@@ -1,33 +1,242 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/rand/v2"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/robfig/cron/v3"
 )
 
 // Config represents the application configuration.
 type Config struct {
-	ShellyURL            string  `json:"shellyTempURL"`        // URL of the Shelly device temperature addon.
-	ShellyHeatingOnURL   string  `json:"shellyHeatingOnURL"`   // URL to turn Shelly heating on.
-	ShellyHeatingOffURL  string  `json:"shellyHeatingOffURL"`  // URL to turn Shelly heating off.
-	TemperatureThreshold float64 `json:"temperatureThreshold"` // Temperature threshold in Celsius.
-	TemperatureTurnOff   float64 `json:"temperatureTurnOff"`   // Temperature at which to turn off the heating.
-	CheckInterval        int     `json:"checkInterval"`        // Check interval in minutes.
-	WeeklyCheckInterval  int     `json:"weeklyCheckInterval"`  // Weekly check interval in hours.
-
+	ShellyURL                 string             `json:"shellyTempURL"`             // URL of the Shelly device temperature addon, kept for single-sensor configs.
+	ShellyURLs                []string           `json:"shellyTempURLs"`            // URLs of all Shelly temperature sensors, the hottest one is used.
+	ShellyHeatingOnURL        string             `json:"shellyHeatingOnURL"`        // URL to turn Shelly heating on.
+	ShellyHeatingOffURL       string             `json:"shellyHeatingOffURL"`       // URL to turn Shelly heating off.
+	TemperatureThreshold      float64            `json:"temperatureThreshold"`      // Temperature threshold in the configured unit.
+	TemperatureTurnOff        float64            `json:"temperatureTurnOff"`        // Temperature at which to turn off the heating.
+	CheckInterval             int                `json:"checkInterval"`             // Check interval in minutes.
+	WeeklyCheckInterval       int                `json:"weeklyCheckInterval"`       // Weekly check interval in hours.
+	HTTPTimeout               int                `json:"httpTimeout"`               // Timeout for Shelly HTTP requests in seconds.
+	MaxRetries                int                `json:"maxRetries"`                // Number of retries for failed temperature reads.
+	RetryBackoff              int                `json:"retryBackoff"`              // Initial retry backoff in milliseconds, doubled on each retry.
+	ShellyGeneration          string             `json:"shellyGeneration"`          // Shelly API generation, "gen1" or "gen2".
+	MetricsPort               int                `json:"metricsPort"`               // Port for the Prometheus metrics endpoint, 0 disables it.
+	HealthPort                int                `json:"healthPort"`                // Port for the /healthz endpoint, 0 disables it.
+	HistoryFile               string             `json:"historyFile"`               // CSV file to append temperature readings to, empty disables it.
+	NotifyURL                 string             `json:"notifyURL"`                 // Webhook receiving heating event notifications, empty disables it.
+	TelegramBotToken          string             `json:"telegramBotToken"`          // Telegram bot token for notifications, empty disables Telegram.
+	TelegramChatID            string             `json:"telegramChatID"`            // Telegram chat receiving the notifications.
+	HeatingDurationMinutes    int                `json:"heatingDurationMinutes"`    // Duration of a legionella heating run in minutes.
+	WeeklyCheckWeekday        string             `json:"weeklyCheckWeekday"`        // Weekday of the weekly check, e.g. "Sunday"; empty uses weeklyCheckInterval.
+	WeeklyCheckHour           int                `json:"weeklyCheckHour"`           // Hour of day (0-23, local time) of the weekly check when weeklyCheckWeekday is set.
+	PVSurplusURL              string             `json:"pvSurplusURL"`              // Inverter endpoint returning the current PV surplus in watts, empty disables PV control.
+	PVSurplusThresholdWatts   float64            `json:"pvSurplusThresholdWatts"`   // PV surplus in watts above which the heating is turned on.
+	VacationMode              bool               `json:"vacationMode"`              // Suspends PV surplus heating while keeping the weekly check; overridden at runtime via the API.
+	LogLevel                  string             `json:"logLevel"`                  // Minimum log level: "debug", "info", "warn" or "error".
+	ShellyUsername            string             `json:"shellyUsername"`            // Username for Shelly digest authentication, empty disables authentication.
+	ShellyPassword            string             `json:"shellyPassword"`            // Password for Shelly digest authentication.
+	InsecureSkipTLSVerify     bool               `json:"insecureSkipTLSVerify"`     // Skip TLS certificate verification for HTTPS requests.
+	ShellyCACert              string             `json:"shellyCACert"`              // PEM file with CA certificates trusted for HTTPS requests.
+	DryRun                    bool               `json:"dryRun"`                    // Log heating switch actions instead of sending them to the Shelly.
+	APIPort                   int                `json:"apiPort"`                   // Port for the REST API, 0 disables it.
+	APIToken                  string             `json:"apiToken"`                  // Bearer token required for POST and DELETE API endpoints, GET /config and GET /diag/shelly-temp, empty leaves them open.
+	ThresholdHysteresis       float64            `json:"thresholdHysteresis"`       // Degrees below the threshold at which the exceeded flag resets, 0 keeps it until the weekly check.
+	MaxSafeTemperature        float64            `json:"maxSafeTemperature"`        // Temperature above which the heating is forced off, 0 disables the cutoff.
+	TemperatureUnit           string             `json:"temperatureUnit"`           // Unit of readings and configured temperatures, "C" or "F".
+	SMTPHost                  string             `json:"smtpHost"`                  // SMTP server for email notifications, empty disables email.
+	SMTPPort                  int                `json:"smtpPort"`                  // SMTP server port, STARTTLS is required.
+	SMTPUsername              string             `json:"smtpUsername"`              // SMTP username, empty sends without authentication.
+	SMTPPassword              string             `json:"smtpPassword"`              // SMTP password.
+	EmailFrom                 string             `json:"emailFrom"`                 // Sender address of notification emails.
+	EmailTo                   string             `json:"emailTo"`                   // Recipient address of notification emails.
+	MaxConsecutiveFailures    int                `json:"maxConsecutiveFailures"`    // Consecutive failed temperature reads after which a notification is sent.
+	SmoothingWindow           int                `json:"smoothingWindow"`           // Number of readings averaged before comparing against the threshold, 1 disables smoothing.
+	MQTTBroker                string             `json:"mqttBroker"`                // MQTT broker URL, e.g. "tcp://homeassistant:1883"; empty disables MQTT.
+	MQTTUsername              string             `json:"mqttUsername"`              // MQTT username, empty connects without authentication.
+	MQTTPassword              string             `json:"mqttPassword"`              // MQTT password.
+	MQTTTopicPrefix           string             `json:"mqttTopicPrefix"`           // Prefix of the state and command topics.
+	ShellyStatusURL           string             `json:"shellyStatusURL"`           // URL of the Shelly relay status used to confirm the heating switched on, empty skips the check.
+	CheckJitterSeconds        int                `json:"checkJitterSeconds"`        // Random deviation of up to ± this many seconds added to each check interval.
+	MinHeatingIntervalHours   int                `json:"minHeatingIntervalHours"`   // Minimum hours between two heating runs, 0 disables the guard.
+	SlackWebhookURL           string             `json:"slackWebhookURL"`           // Slack incoming webhook for notifications, empty disables Slack.
+	ShellyTempFallbackURL     string             `json:"shellyTempFallbackURL"`     // Temperature URL read only when none of the primary sensors can be read, empty disables it.
+	PasteurizationTemp        float64            `json:"pasteurizationTemp"`        // Temperature counted towards pasteurization, 0 skips the weekly heating after any reading above the threshold.
+	PasteurizationMinutes     int                `json:"pasteurizationMinutes"`     // Minutes the tank must stay at pasteurizationTemp during the week to skip the weekly heating.
+	LogFile                   string             `json:"logFile"`                   // File to write logs to with size-based rotation, empty logs to stdout.
+	LogMaxSizeMB              int                `json:"logMaxSizeMB"`              // Size in megabytes at which the log file is rotated.
+	LogMaxBackups             int                `json:"logMaxBackups"`             // Number of rotated log files to keep.
+	ShellyRelayURL            string             `json:"shellyRelayURL"`            // Base URL of the Shelly relay, e.g. "http://192.168.1.20"; derives missing heating on/off URLs for shellyGeneration.
+	ShellyRelayID             int                `json:"shellyRelayID"`             // ID of the relay switched when shellyRelayURL is set.
+	ActiveHoursStart          int                `json:"activeHoursStart"`          // Hour of day (0-23, local time) at which the active polling window starts.
+	ActiveHoursEnd            int                `json:"activeHoursEnd"`            // Hour of day (0-23, local time) at which the active polling window ends, equal to activeHoursStart disables the window.
+	InactiveCheckInterval     int                `json:"inactiveCheckInterval"`     // Check interval in minutes outside the active hours, 0 skips checks there.
+	MaxResponseBytes          int                `json:"maxResponseBytes"`          // Maximum size of a temperature response body in bytes, larger responses are rejected.
+	RetryDeadlineFraction     float64            `json:"retryDeadlineFraction"`     // Fraction of checkInterval a temperature read including retries may take.
+	MaxBackoffSeconds         int                `json:"maxBackoffSeconds"`         // Upper bound of the doubled retry backoff in seconds.
+	TempJSONPath              string             `json:"tempJSONPath"`              // Dotted path to the temperature in the response JSON, e.g. "result.tC"; empty uses the Shelly format.
+	Timezone                  string             `json:"timezone"`                  // IANA time zone used for scheduling and log timestamps, e.g. "Europe/Zurich"; empty uses the system zone.
+	HistorySize               int                `json:"historySize"`               // Number of recent readings kept in memory for GET /metrics/temperature.
+	BatterySOCURL             string             `json:"batterySOCURL"`             // Endpoint returning the home battery state of charge in percent, empty disables the battery check.
+	MinBatterySOC             float64            `json:"minBatterySOC"`             // State of charge in percent below which heating is deferred.
+	MaxBatteryDeferralHours   int                `json:"maxBatteryDeferralHours"`   // Hours the weekly heating may be deferred for the battery before it runs anyway.
+	UserAgent                 string             `json:"userAgent"`                 // User-Agent header sent with all HTTP requests, empty uses pv_heating_manager/<version>.
+	InfluxURL                 string             `json:"influxURL"`                 // Base URL of the InfluxDB server, empty disables writing readings to InfluxDB.
+	InfluxToken               string             `json:"influxToken"`               // API token for the InfluxDB write API.
+	InfluxOrg                 string             `json:"influxOrg"`                 // InfluxDB organization.
+	InfluxBucket              string             `json:"influxBucket"`              // InfluxDB bucket the readings are written to.
+	NotifyMinSeverity         string             `json:"notifyMinSeverity"`         // Minimum severity sent to the webhook ("info", "warning" or "critical"), empty sends all events.
+	TelegramMinSeverity       string             `json:"telegramMinSeverity"`       // Minimum severity sent to Telegram, empty sends all events.
+	SlackMinSeverity          string             `json:"slackMinSeverity"`          // Minimum severity sent to Slack, empty sends the default Slack events.
+	EmailMinSeverity          string             `json:"emailMinSeverity"`          // Minimum severity sent by email, empty sends the default email events.
+	ShellyPowerURL            string             `json:"shellyPowerURL"`            // URL reporting the power draw of the heating element, empty skips the power check.
+	MinHeatingWatts           float64            `json:"minHeatingWatts"`           // Power draw in watts that confirms the heating element is heating.
+	StartupGracePeriodMinutes int                `json:"startupGracePeriodMinutes"` // Minutes to wait after startup before the first weekly check if none has run yet.
+	ThresholdSustainMinutes   int                `json:"thresholdSustainMinutes"`   // Minutes the temperature must stay above the threshold before it counts as exceeded, 0 counts a single reading.
+	HTTPBasePath              string             `json:"httpBasePath"`              // Path prefix of all HTTP endpoints when served behind a reverse proxy, e.g. "/pvheat", empty serves them at the root.
+	HTTPAuthUser              string             `json:"httpAuthUser"`              // User name for HTTP Basic Auth on the API, metrics and health endpoints, empty leaves them open.
+	HTTPAuthPassword          string             `json:"httpAuthPassword"`          // Password for HTTP Basic Auth.
+	HTTPAuthExcludeHealthz    bool               `json:"httpAuthExcludeHealthz"`    // Serve /healthz without Basic Auth, so probes need no credentials.
+	VerifyAfterMinutes        int                `json:"verifyAfterMinutes"`        // Minutes after the weekly heating turned on to check that the tank reached the target, 0 disables the check.
+	OnHeatingCommand          string             `json:"onHeatingCommand"`          // Shell command run after the heating was switched on or off, empty runs none.
+	OnHeatingFailCommand      string             `json:"onHeatingFailCommand"`      // Shell command run after switching the heating on or off failed, empty runs none.
+	HookTimeoutSeconds        int                `json:"hookTimeoutSeconds"`        // Seconds a heating command may run before it is killed.
+	TemperaturePrecision      *int               `json:"temperaturePrecision"`      // Decimal places temperatures are rounded to in logs, the API, the dashboard and metrics, nil for the default.
+	Zones                     []ZoneConfig       `json:"zones"`                     // Independent tanks managed by this process, each with its own sensors, relay and schedule; empty manages the single tank configured above.
+	WeeklyCheckCron           string             `json:"weeklyCheckCron"`           // Cron expression of the weekly check, e.g. "0 3 * * 0" for Sundays at 3am; takes precedence over weeklyCheckWeekday and weeklyCheckInterval.
+	TemperatureOffset         float64            `json:"temperatureOffset"`         // Calibration offset in °C added to every Shelly sensor reading.
+	SensorOffsets             map[string]float64 `json:"sensorOffsets"`             // Calibration offsets in °C by sensor URL, replacing temperatureOffset for that sensor.
+	ShellyWebSocketURL        string             `json:"shellyWebSocketURL"`        // Gen2 WebSocket RPC endpoint, e.g. "ws://shelly/rpc", pushing temperatures instead of polling; empty polls.
 }
 
+// Supported Shelly API generations.
+const (
+	shellyGen1 = "gen1"
+	shellyGen2 = "gen2"
+)
+
+// Defaults for optional configuration values.
+const (
+	defaultHTTPTimeout          = 10
+	defaultMaxRetries           = 3
+	defaultRetryBackoff         = 500
+	defaultHeatingDuration      = 240
+	defaultSMTPPort             = 587
+	defaultMaxFailures          = 3
+	defaultMQTTTopicPrefix      = "heating_manager"
+	defaultLogMaxSizeMB         = 10
+	defaultLogMaxBackups        = 3
+	defaultMaxResponse          = 1 << 20
+	defaultRetryDeadline        = 0.5
+	defaultMaxBackoff           = 30
+	defaultHistorySize          = 288
+	defaultMaxDeferral          = 24
+	defaultMinHeatingWatts      = 100
+	defaultHookTimeout          = 30
+	defaultTemperaturePrecision = 1
+)
+
+// responseSnippetLength is the number of response body bytes quoted in parse errors.
+const responseSnippetLength = 100
+
+// heatingCheckInterval is the interval between temperature checks while heating.
+const heatingCheckInterval = 5 * time.Minute
+
+// errHeatingTooSoon is returned by turnHeatingOn when the previous heating run is more recent than the minimum interval.
+var errHeatingTooSoon = errors.New("previous heating run is more recent than the minimum heating interval")
+
+// errHeatingInProgress is returned by turnHeatingOn while another call is switching the heating on
+// or a heating cycle is still running.
+var errHeatingInProgress = errors.New("a heating run is already in progress")
+
+// errCheckInProgress is returned by checkTemperature when the previous check has not finished yet.
+var errCheckInProgress = errors.New("previous temperature check is still running")
+
+// weeklyCheckRetryDelay is the delay before retrying a failed weekly check.
+var weeklyCheckRetryDelay = 15 * time.Minute
+
+// heatingOffRetryDelay is the delay before retrying a failed heating off call.
+var heatingOffRetryDelay = 30 * time.Second
+
 // HeatingManager is the main application struct.
 type HeatingManager struct {
-	Config              Config        // Configuration.
-	TemperatureExceeded bool          // Indicates if the temperature threshold has been exceeded.
-	CheckInterval       time.Duration // Interval between temperature checks.
-	LastCheckFile       string        // File to save and read the last check time.
+	Config              Config              // Configuration, guarded by configMu.
+	TemperatureExceeded bool                // Indicates if the temperature threshold has been exceeded, guarded by mu.
+	CheckInterval       time.Duration       // Interval between temperature checks, guarded by configMu.
+	LastCheckFile       string              // Legacy last check time file, migrated into the state file.
+	StateFile           string              // File persisting the manager state across restarts.
+	HTTPClient          HTTPClient          // HTTP client used for all outgoing requests.
+	TemperatureSources  []TemperatureSource // Primary temperature sources; empty reads the Shelly sensors in ShellyURLs.
+	HeatingController   HeatingController   // Switches the heating; nil switches the Shelly relay at the configured URLs.
+	Clock               Clock               // Clock used for scheduling and state timestamps.
+	LastTemperature     float64             // Last successfully read temperature, guarded by mu.
+	LastReadTime        time.Time           // Time of the last successful temperature read, guarded by mu.
+	LogOutput           io.Writer           // Destination of the log, stdout or the rotating log file.
+	Zone                string              // Name of the zone heated by this manager, empty for a single-zone config.
+
+	zones []*HeatingManager // Managers of the configured zones, empty for a single-zone config.
+
+	configMu      sync.RWMutex  // Guards Config and CheckInterval.
+	configChanged chan struct{} // Closed and replaced whenever a new config is applied, guarded by configMu.
+
+	mu             sync.Mutex // Guards the exported state fields, the fields below and cancelHeating.
+	weeklyMu       sync.Mutex // Serializes weekly checks.
+	stateMu        sync.Mutex // Serializes writes to the state file.
+	lastExceeded   time.Time  // Time the threshold was last exceeded.
+	lastCheck      time.Time  // Time of the last weekly check.
+	lastHeatingRun time.Time  // Time the heating was last turned on.
+
+	heatingFailedAt time.Time // Time the weekly heating first failed since the last completed weekly check, zero if none failed.
+
+	lastSafetyCutoff        time.Time // Time the safety cutoff was last triggered.
+	safetyCutoffTemperature float64   // Temperature that triggered the last safety cutoff.
+
+	startTime          time.Time // Time the manager was created.
+	successfulReads    int64     // Total successful temperature reads, persisted.
+	failedReads        int64     // Total failed temperature reads, persisted.
+	heatingActivations int64     // Total times the heating was turned on, persisted.
+
+	pasteurizedDuration    time.Duration   // Time at or above PasteurizationTemp since the last weekly check.
+	lastPasteurizationRead time.Time       // Time of the previous reading at or above PasteurizationTemp, zero if it was below.
+	crossedAboveAt         time.Time       // Time the smoothed temperature crossed above the threshold, zero while below.
+	batteryDeferredSince   time.Time       // Time the weekly heating was first deferred for the battery, zero if it is not deferred.
+	skipNextWeekly         bool            // Skip the next weekly heating by manual override.
+	aboveThreshold         bool            // Whether the last smoothed reading was above the threshold.
+	cooldown               *Cooldown       // Cooldown since the last threshold crossing, nil before the first one.
+	weeklyOutcomes         []WeeklyOutcome // Recent weekly check outcomes, oldest first.
+
+	historyMu      sync.Mutex     // Serializes writes to the history file.
+	recentReadings *ring[Reading] // Recent readings served by GET /metrics/temperature, guarded by mu.
+
+	checkMu             sync.Mutex     // Held while checkTemperature runs, so checks never overlap.
+	consecutiveFailures int            // Number of temperature reads that failed in a row, guarded by checkMu.
+	readings            *ring[float64] // Recent readings for smoothing, only used by checkTemperature.
+
+	heatingMu        sync.Mutex         // Held while turnHeatingOn starts a heating run, so runs never overlap.
+	cancelHeating    context.CancelFunc // Cancels the supervision of the running heating cycle, guarded by mu.
+	cancelVerify     context.CancelFunc // Cancels the pending heating verification, guarded by mu.
+	heatingWG        sync.WaitGroup     // Tracks running heating cycle supervisions and verifications.
+	hookWG           sync.WaitGroup     // Tracks running heating commands.
+	pvHeating        bool               // Indicates if the heating is currently on because of PV surplus, guarded by mu.
+	vacationOverride *bool              // Vacation mode set via the API, overriding Config.VacationMode while set, guarded by mu.
+	heatingOn        bool               // Indicates if the heating was last switched on, guarded by mu.
+	heatingOffAt     time.Time          // Scheduled end of the running heating cycle, zero if none, guarded by mu.
+	heatingOffFailed bool               // Indicates if turning off the heating at the end of its cycle failed, guarded by mu.
+
+	mqtt mqttPublisher // Publishes state to the MQTT broker while connected, guarded by mu.
 }
 
 type TempResponse struct {
@@ -36,202 +245,1316 @@ type TempResponse struct {
 	TF float64 `json:"tF"`
 }
 
-// NewHeatingManager creates a new HeatingManager instance.
-func NewHeatingManager() (*HeatingManager, error) {
-	config, err := loadConfig()
+// RPCTempResponse is the Shelly Gen2 RPC response wrapping a TempResponse in a result object.
+type RPCTempResponse struct {
+	ID     int           `json:"id"`
+	Result *TempResponse `json:"result"`
+}
+
+// NewHeatingManager creates a new HeatingManager instance from the config file at configPath.
+func NewHeatingManager(configPath string) (*HeatingManager, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewHeatingManagerWithConfig(config)
+}
+
+// NewHeatingManagerWithConfig creates a new HeatingManager instance from config without reading a config file.
+// Missing optional settings are filled in with their defaults before the config is validated.
+// Each configured zone gets its own manager with its own state file.
+func NewHeatingManagerWithConfig(config Config) (*HeatingManager, error) {
+	hm, err := newHeatingManager(config)
+	if err != nil {
+		return nil, err
+	}
+	hm.restoreState()
+	if err := hm.newZoneManagers(); err != nil {
+		return nil, err
+	}
+	return hm, nil
+}
+
+// newHeatingManager creates a HeatingManager from config without restoring its state.
+func newHeatingManager(config Config) (*HeatingManager, error) {
+	config.setDefaults()
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	client, err := newHTTPClient(config)
 	if err != nil {
 		return nil, err
 	}
 
-	return &HeatingManager{
+	hm := &HeatingManager{
 		Config:        config,
 		CheckInterval: time.Duration(config.CheckInterval) * time.Minute,
 		LastCheckFile: "lastCheck.txt",
-	}, nil
+		StateFile:     "state.json",
+		HTTPClient:    client,
+		configChanged: make(chan struct{}),
+		Clock:         realClock{},
+		LogOutput:     os.Stdout,
+	}
+	hm.startTime = hm.Clock.Now()
+	return hm, nil
 }
 
 // StartTemperatureMonitoring starts the temperature monitoring loop.
-func (hm *HeatingManager) StartTemperatureMonitoring() {
-	ticker := time.NewTicker(hm.CheckInterval)
-	defer ticker.Stop()
+// It returns when the context is cancelled. With ShellyWebSocketURL set, the device pushes the readings
+// instead, see monitorWebSocket.
+func (hm *HeatingManager) StartTemperatureMonitoring(ctx context.Context) {
+	if hm.currentConfig().ShellyWebSocketURL != "" {
+		hm.monitorWebSocket(ctx)
+		return
+	}
+	hm.pollTemperature(ctx)
+}
 
-	for range ticker.C {
-		hm.checkTemperature(hm.Config.ShellyURL)
+// pollTemperature checks the temperature until the context is cancelled. Each wait is jittered by up to
+// CheckJitterSeconds; a reloaded config reschedules the next check. Outside the active hours, checks are
+// polled at InactiveCheckInterval or skipped.
+func (hm *HeatingManager) pollTemperature(ctx context.Context) {
+	checkTimer := time.NewTimer(hm.nextCheckDelay())
+	defer checkTimer.Stop()
+
+	for {
+		configChanged := hm.configChangedChan()
+		select {
+		case <-ctx.Done():
+			return
+		case <-configChanged:
+			if !checkTimer.Stop() {
+				<-checkTimer.C
+			}
+			checkTimer.Reset(hm.nextCheckDelay())
+		case <-checkTimer.C:
+			hm.retryHeatingOff(ctx)
+			if hm.shouldCheckTemperature() {
+				hm.checkTemperature(ctx, hm.currentConfig().ShellyURLs)
+			}
+			checkTimer.Reset(hm.nextCheckDelay())
+		}
 	}
 }
 
+// readDeadline returns the time a temperature read including its retries may take.
+func (hm *HeatingManager) readDeadline() time.Duration {
+	return time.Duration(hm.currentConfig().RetryDeadlineFraction * float64(hm.checkInterval()))
+}
+
+// nextCheckDelay returns the poll interval with a random jitter of up to ±CheckJitterSeconds.
+func (hm *HeatingManager) nextCheckDelay() time.Duration {
+	interval := hm.pollInterval()
+	jitter := time.Duration(hm.currentConfig().CheckJitterSeconds) * time.Second
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(int64(2*jitter)+1)) - jitter
+}
+
 // StartWeeklyCheck starts the weekly check loop.
-func (hm *HeatingManager) StartWeeklyCheck() {
-	weeklyCheckTimer := time.NewTimer(hm.nextWeeklyCheckDuration())
+// It returns when the context is cancelled, abandoning any pending heating off call.
+// A failed check is retried after weeklyCheckRetryDelay, a check deferred for the battery after batteryRetryDelay;
+// a reloaded config, e.g. a changed weeklyCheckInterval, reschedules the next check right away, and cancelling
+// the context ends the wait immediately. The next check time is recomputed at least every
+// weeklyRecheckInterval, so a clock jump neither runs the check early nor delays it indefinitely.
+func (hm *HeatingManager) StartWeeklyCheck(ctx context.Context) {
+	// The config channel is taken before each delay is computed, so a reload in between is not missed.
+	configChanged := hm.configChangedChan()
+	delay := hm.weeklyCheckDelay()
+	if hm.NextWeeklyCheck().IsZero() && delay > 0 {
+		slog.Info("No weekly check has run yet, waiting for the startup grace period", "delay", hm.nextWeeklyCheckDuration())
+	}
+	weeklyCheckTimer := time.NewTimer(delay)
 	defer weeklyCheckTimer.Stop()
+	defer hm.cancelHeatingOff()
+	hm.resumeHeatingOff(ctx)
+	watch := newClockWatch(hm.Clock)
 
-	for range weeklyCheckTimer.C {
-		hm.weeklyCheck(hm.Config.ShellyHeatingOnURL, hm.Config.ShellyHeatingOffURL)
-		weeklyCheckTimer.Reset(hm.nextWeeklyCheckDuration())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-configChanged:
+			configChanged = hm.configChangedChan()
+			if !weeklyCheckTimer.Stop() {
+				<-weeklyCheckTimer.C
+			}
+			weeklyCheckTimer.Reset(hm.weeklyCheckDelay())
+		case <-weeklyCheckTimer.C:
+			if jump := watch.jump(); jump.Abs() > clockJumpThreshold {
+				slog.Warn("System clock jumped, recomputing the next weekly check", "jump", jump)
+			}
+			if delay := hm.weeklyCheckDelay(); delay > 0 {
+				weeklyCheckTimer.Reset(delay)
+				continue
+			}
+			config := hm.currentConfig()
+			err := hm.weeklyCheck(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL), false)
+			if errors.Is(err, errHeatingDeferred) {
+				weeklyCheckTimer.Reset(batteryRetryDelay)
+				continue
+			}
+			if err != nil {
+				slog.Error("Weekly check failed, retrying", "event", eventHeatingOn, "backoff", weeklyCheckRetryDelay, "err", err)
+				weeklyCheckTimer.Reset(weeklyCheckRetryDelay)
+				continue
+			}
+			weeklyCheckTimer.Reset(hm.weeklyCheckDelay())
+		}
 	}
 }
 
-// loadConfig loads the application configuration from a JSON file.
-func loadConfig() (Config, error) {
+// loadConfig loads the application configuration from a JSON file and overlays the PV_* environment
+// variables listed in envOverrides. A missing file is only accepted if environment variables are set.
+func loadConfig(path string) (Config, error) {
 	var config Config
-	configFile, err := os.Open("config.json")
-	if err != nil {
+	configFile, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// The config may come from the environment alone.
+	case err != nil:
 		return config, fmt.Errorf("failed to open config file: %v", err)
+	default:
+		defer configFile.Close()
+		if err := json.NewDecoder(configFile).Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse config file: %v", err)
+		}
 	}
-	defer configFile.Close()
 
-	err = json.NewDecoder(configFile).Decode(&config)
-	if err != nil {
-		return config, fmt.Errorf("failed to parse config file: %v", err)
+	fromEnv, envErr := config.applyEnv()
+	if envErr != nil {
+		return config, envErr
+	}
+	if configFile == nil {
+		if !fromEnv {
+			return config, fmt.Errorf("failed to open config file: %v", err)
+		}
+		slog.Info("Config file not found, using environment variables and defaults", "path", path)
+	}
+
+	config.setDefaults()
+	if err := config.validate(); err != nil {
+		return config, err
 	}
 
 	return config, nil
 }
 
-// checkTemperature checks the temperature of a Shelly device.
-func (hm *HeatingManager) checkTemperature(shellyURL string) {
-	temperature, err := getTemperature(shellyURL)
+// setDefaults fills in default values for optional settings.
+func (c *Config) setDefaults() {
+	if c.HTTPTimeout <= 0 {
+		c.HTTPTimeout = defaultHTTPTimeout
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+	if c.RetryBackoff <= 0 {
+		c.RetryBackoff = defaultRetryBackoff
+	}
+	if c.RetryDeadlineFraction <= 0 {
+		c.RetryDeadlineFraction = defaultRetryDeadline
+	}
+	if c.MaxBackoffSeconds <= 0 {
+		c.MaxBackoffSeconds = defaultMaxBackoff
+	}
+	if c.ShellyGeneration == "" {
+		c.ShellyGeneration = shellyGen1
+	}
+	if c.TemperatureUnit == "" {
+		c.TemperatureUnit = unitCelsius
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
+	if c.HeatingDurationMinutes <= 0 {
+		c.HeatingDurationMinutes = defaultHeatingDuration
+	}
+	if c.LogMaxSizeMB <= 0 {
+		c.LogMaxSizeMB = defaultLogMaxSizeMB
+	}
+	if c.LogMaxBackups <= 0 {
+		c.LogMaxBackups = defaultLogMaxBackups
+	}
+	if c.MQTTTopicPrefix == "" {
+		c.MQTTTopicPrefix = defaultMQTTTopicPrefix
+	}
+	if c.MinHeatingWatts <= 0 {
+		c.MinHeatingWatts = defaultMinHeatingWatts
+	}
+	if c.HookTimeoutSeconds <= 0 {
+		c.HookTimeoutSeconds = defaultHookTimeout
+	}
+	if c.TemperaturePrecision == nil {
+		precision := defaultTemperaturePrecision
+		c.TemperaturePrecision = &precision
+	}
+	if c.MaxBatteryDeferralHours <= 0 {
+		c.MaxBatteryDeferralHours = defaultMaxDeferral
+	}
+	if c.HistorySize <= 0 {
+		c.HistorySize = defaultHistorySize
+	}
+	if c.SmoothingWindow <= 0 {
+		c.SmoothingWindow = 1
+	}
+	if c.MaxConsecutiveFailures <= 0 {
+		c.MaxConsecutiveFailures = defaultMaxFailures
+	}
+	if c.MaxResponseBytes <= 0 {
+		c.MaxResponseBytes = defaultMaxResponse
+	}
+	if c.SMTPPort <= 0 {
+		c.SMTPPort = defaultSMTPPort
+	}
+	if len(c.ShellyURLs) == 0 && c.ShellyURL != "" {
+		c.ShellyURLs = []string{c.ShellyURL}
+	}
+	if c.ShellyRelayURL != "" {
+		if c.ShellyHeatingOnURL == "" {
+			c.ShellyHeatingOnURL = shellyRelayURL(c.ShellyRelayURL, c.ShellyGeneration, c.ShellyRelayID, true)
+		}
+		if c.ShellyHeatingOffURL == "" {
+			c.ShellyHeatingOffURL = shellyRelayURL(c.ShellyRelayURL, c.ShellyGeneration, c.ShellyRelayID, false)
+		}
+	}
+	c.HTTPBasePath = strings.TrimRight(c.HTTPBasePath, "/")
+}
+
+// Plausible range for configured temperatures in Celsius.
+const (
+	minConfigTemperature = -50
+	maxConfigTemperature = 150
+)
+
+// location returns the configured time zone, or the system zone if none is set.
+// The zone must have passed config validation.
+func (c *Config) location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(c.Timezone)
 	if err != nil {
-		log.Printf("Failed to get temperature: %v", err)
-		return
+		return time.Local
 	}
+	return location
+}
 
-	if temperature > hm.Config.TemperatureThreshold {
-		fmt.Printf("Temperature has exceeded %.1f°C! Legionella heating will be rescheduled.\n", hm.Config.TemperatureThreshold)
-		hm.TemperatureExceeded = true
-	} else {
-		fmt.Printf("Temperature is OK. Actual temperature: %.1f°C\n", temperature)
+// Supported temperature units.
+const (
+	unitCelsius    = "C"
+	unitFahrenheit = "F"
+)
+
+// celsiusToUnit converts a Celsius temperature to the given unit.
+func celsiusToUnit(celsius float64, unit string) float64 {
+	if unit == unitFahrenheit {
+		return celsius*9/5 + 32
 	}
+	return celsius
 }
 
-// getTemperature gets the temperature of a Shelly device.
-func getTemperature(shellyTempURL string) (float64, error) {
-	resp, err := http.Get(shellyTempURL)
+// toCelsius converts a temperature in the configured unit to Celsius.
+func (c *Config) toCelsius(temperature float64) float64 {
+	if c.TemperatureUnit == unitFahrenheit {
+		return (temperature - 32) * 5 / 9
+	}
+	return temperature
+}
+
+// checkTemperatureRange rejects configured temperatures outside the plausible range.
+func (c *Config) checkTemperatureRange(field string, temperature float64) error {
+	min := celsiusToUnit(minConfigTemperature, c.TemperatureUnit)
+	max := celsiusToUnit(maxConfigTemperature, c.TemperatureUnit)
+	if temperature < min || temperature > max {
+		return fmt.Errorf("invalid config: %s must be between %.0f and %.0f°%s, got %.1f", field, min, max, c.TemperatureUnit, temperature)
+	}
+	return nil
+}
+
+// validate checks the configuration for values the manager cannot run with.
+// The returned error names the offending config field.
+func (c *Config) validate() error {
+	if len(c.Zones) > 0 {
+		if err := c.validateZones(); err != nil {
+			return err
+		}
+	} else if err := c.validateDevices(); err != nil {
+		return err
+	}
+	if c.CheckInterval <= 0 {
+		return fmt.Errorf("invalid config: checkInterval must be positive, got %d", c.CheckInterval)
+	}
+	if c.CheckJitterSeconds < 0 || c.CheckJitterSeconds >= c.CheckInterval*60 {
+		return fmt.Errorf("invalid config: checkJitterSeconds must be non-negative and shorter than checkInterval, got %d", c.CheckJitterSeconds)
+	}
+	if c.ActiveHoursStart < 0 || c.ActiveHoursStart > 23 {
+		return fmt.Errorf("invalid config: activeHoursStart must be between 0 and 23, got %d", c.ActiveHoursStart)
+	}
+	if c.ActiveHoursEnd < 0 || c.ActiveHoursEnd > 23 {
+		return fmt.Errorf("invalid config: activeHoursEnd must be between 0 and 23, got %d", c.ActiveHoursEnd)
+	}
+	if c.StartupGracePeriodMinutes < 0 {
+		return fmt.Errorf("invalid config: startupGracePeriodMinutes must not be negative, got %d", c.StartupGracePeriodMinutes)
+	}
+	if c.InactiveCheckInterval < 0 {
+		return fmt.Errorf("invalid config: inactiveCheckInterval must not be negative, got %d", c.InactiveCheckInterval)
+	}
+	if c.RetryDeadlineFraction > 1 {
+		return fmt.Errorf("invalid config: retryDeadlineFraction must not exceed 1, got %.2f", c.RetryDeadlineFraction)
+	}
+	if c.WeeklyCheckInterval <= 0 {
+		return fmt.Errorf("invalid config: weeklyCheckInterval must be positive, got %d", c.WeeklyCheckInterval)
+	}
+	if c.TemperatureUnit != unitCelsius && c.TemperatureUnit != unitFahrenheit {
+		return fmt.Errorf("invalid config: temperatureUnit must be %q or %q, got %q", unitCelsius, unitFahrenheit, c.TemperatureUnit)
+	}
+	if err := c.checkTemperatureRange("temperatureThreshold", c.TemperatureThreshold); err != nil {
+		return err
+	}
+	if err := c.checkTemperatureRange("temperatureTurnOff", c.TemperatureTurnOff); err != nil {
+		return err
+	}
+	if c.WeeklyCheckWeekday != "" {
+		if _, err := parseWeekday(c.WeeklyCheckWeekday); err != nil {
+			return fmt.Errorf("invalid config: weeklyCheckWeekday: %v", err)
+		}
+		if c.WeeklyCheckHour < 0 || c.WeeklyCheckHour > 23 {
+			return fmt.Errorf("invalid config: weeklyCheckHour must be between 0 and 23, got %d", c.WeeklyCheckHour)
+		}
+	}
+	if c.WeeklyCheckCron != "" {
+		if c.WeeklyCheckWeekday != "" {
+			return fmt.Errorf("invalid config: weeklyCheckCron and weeklyCheckWeekday must not both be set")
+		}
+		schedule, err := parseWeeklyCron(c.WeeklyCheckCron)
+		if err != nil {
+			return fmt.Errorf("invalid config: weeklyCheckCron: %v", err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("invalid config: weeklyCheckCron %q never matches", c.WeeklyCheckCron)
+		}
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		return fmt.Errorf("invalid config: timezone: %v", err)
+	}
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("invalid config: logLevel must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	if c.MaxSafeTemperature != 0 {
+		if err := c.checkTemperatureRange("maxSafeTemperature", c.MaxSafeTemperature); err != nil {
+			return err
+		}
+	}
+	if c.PasteurizationTemp != 0 {
+		if err := c.checkTemperatureRange("pasteurizationTemp", c.PasteurizationTemp); err != nil {
+			return err
+		}
+		if c.PasteurizationMinutes <= 0 {
+			return fmt.Errorf("invalid config: pasteurizationMinutes must be positive when pasteurizationTemp is set, got %d", c.PasteurizationMinutes)
+		}
+	}
+	if c.MinBatterySOC < 0 || c.MinBatterySOC > 100 {
+		return fmt.Errorf("invalid config: minBatterySOC must be between 0 and 100, got %.1f", c.MinBatterySOC)
+	}
+	if c.MinHeatingIntervalHours < 0 {
+		return fmt.Errorf("invalid config: minHeatingIntervalHours must not be negative, got %d", c.MinHeatingIntervalHours)
+	}
+	if c.ThresholdHysteresis < 0 {
+		return fmt.Errorf("invalid config: thresholdHysteresis must not be negative, got %.1f", c.ThresholdHysteresis)
+	}
+	if c.VerifyAfterMinutes < 0 {
+		return fmt.Errorf("invalid config: verifyAfterMinutes must not be negative, got %d", c.VerifyAfterMinutes)
+	}
+	if c.ThresholdSustainMinutes < 0 {
+		return fmt.Errorf("invalid config: thresholdSustainMinutes must not be negative, got %d", c.ThresholdSustainMinutes)
+	}
+	if err := c.validateOffsets(); err != nil {
+		return err
+	}
+	if precision := c.precision(); precision < 0 || precision > maxTemperaturePrecision {
+		return fmt.Errorf("invalid config: temperaturePrecision must be between 0 and %d, got %d", maxTemperaturePrecision, precision)
+	}
+	if c.HTTPBasePath != "" && !strings.HasPrefix(c.HTTPBasePath, "/") {
+		return fmt.Errorf("invalid config: httpBasePath must start with /, got %q", c.HTTPBasePath)
+	}
+	if (c.HTTPAuthUser == "") != (c.HTTPAuthPassword == "") {
+		return fmt.Errorf("invalid config: httpAuthUser and httpAuthPassword must be set together")
+	}
+	for field, severity := range map[string]string{
+		"notifyMinSeverity":   c.NotifyMinSeverity,
+		"telegramMinSeverity": c.TelegramMinSeverity,
+		"slackMinSeverity":    c.SlackMinSeverity,
+		"emailMinSeverity":    c.EmailMinSeverity,
+	} {
+		if _, ok := severityRanks[severity]; severity != "" && !ok {
+			return fmt.Errorf("invalid config: %s must be info, warning or critical, got %q", field, severity)
+		}
+	}
+	if c.SMTPHost != "" && (c.EmailFrom == "" || c.EmailTo == "") {
+		return fmt.Errorf("invalid config: emailFrom and emailTo must be set when smtpHost is set")
+	}
+	if c.InfluxURL != "" && c.InfluxBucket == "" {
+		return fmt.Errorf("invalid config: influxBucket must be set when influxURL is set")
+	}
+	if c.TempJSONPath != "" && slices.Contains(strings.Split(c.TempJSONPath, "."), "") {
+		return fmt.Errorf("invalid config: tempJSONPath must not contain empty segments, got %q", c.TempJSONPath)
+	}
+	if c.ShellyGeneration != shellyGen1 && c.ShellyGeneration != shellyGen2 {
+		return fmt.Errorf("invalid config: shellyGeneration must be %q or %q, got %q", shellyGen1, shellyGen2, c.ShellyGeneration)
+	}
+	return nil
+}
+
+// validateDevices checks that the temperature sensors and the heating relay are configured.
+func (c *Config) validateDevices() error {
+	if len(c.ShellyURLs) == 0 {
+		return fmt.Errorf("invalid config: shellyTempURL or shellyTempURLs must be set")
+	}
+	for i, url := range c.ShellyURLs {
+		if url == "" {
+			return fmt.Errorf("invalid config: shellyTempURLs[%d] must not be empty", i)
+		}
+	}
+	if c.ShellyHeatingOnURL == "" {
+		return fmt.Errorf("invalid config: shellyHeatingOnURL or shellyRelayURL must be set")
+	}
+	if c.ShellyHeatingOffURL == "" {
+		return fmt.Errorf("invalid config: shellyHeatingOffURL or shellyRelayURL must be set")
+	}
+	if c.ShellyWebSocketURL != "" {
+		if !strings.HasPrefix(c.ShellyWebSocketURL, "ws://") && !strings.HasPrefix(c.ShellyWebSocketURL, "wss://") {
+			return fmt.Errorf("invalid config: shellyWebSocketURL must be a ws:// or wss:// URL, got %q", c.ShellyWebSocketURL)
+		}
+		if c.ShellyGeneration != shellyGen2 {
+			return fmt.Errorf("invalid config: shellyWebSocketURL requires shellyGeneration %q", shellyGen2)
+		}
+		if c.ShellyUsername != "" {
+			return fmt.Errorf("invalid config: shellyWebSocketURL cannot be used with shellyUsername, devices with authentication are polled")
+		}
+	}
+	return nil
+}
+
+// currentConfig returns a snapshot of the current configuration.
+// Once the loops are running, Config must only be read through currentConfig and replaced through applyConfig.
+// Snapshots share their slices, which are therefore never modified in place.
+func (hm *HeatingManager) currentConfig() Config {
+	hm.configMu.RLock()
+	defer hm.configMu.RUnlock()
+	return hm.Config
+}
+
+// checkInterval returns the current interval between temperature checks.
+func (hm *HeatingManager) checkInterval() time.Duration {
+	hm.configMu.RLock()
+	defer hm.configMu.RUnlock()
+	return hm.CheckInterval
+}
+
+// configChangedChan returns a channel that is closed once a new config is applied.
+func (hm *HeatingManager) configChangedChan() <-chan struct{} {
+	hm.configMu.RLock()
+	defer hm.configMu.RUnlock()
+	return hm.configChanged
+}
+
+// applyConfig swaps in a new configuration and wakes up the loops waiting on configChangedChan.
+func (hm *HeatingManager) applyConfig(config Config) {
+	hm.configMu.Lock()
+	defer hm.configMu.Unlock()
+	hm.Config = config
+	hm.CheckInterval = time.Duration(config.CheckInterval) * time.Minute
+	if hm.configChanged != nil {
+		close(hm.configChanged)
+	}
+	hm.configChanged = make(chan struct{})
+}
+
+// CurrentTemperature returns the last successfully read temperature and the time it was read.
+// The time is zero if no temperature has been read yet.
+func (hm *HeatingManager) CurrentTemperature() (float64, time.Time) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.LastTemperature, hm.LastReadTime
+}
+
+// isTemperatureExceeded reports whether the threshold has been exceeded since the last weekly check.
+func (hm *HeatingManager) isTemperatureExceeded() bool {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.TemperatureExceeded
+}
+
+// setTemperatureExceeded updates the threshold flag and its metric.
+// Callers persist the change with saveState.
+func (hm *HeatingManager) setTemperatureExceeded(exceeded bool) {
+	hm.mu.Lock()
+	hm.TemperatureExceeded = exceeded
+	if exceeded {
+		hm.lastExceeded = hm.Clock.Now()
+	}
+	hm.mu.Unlock()
+	thresholdExceededGauge.WithLabelValues(hm.zoneName()).Set(boolToFloat(exceeded))
+}
+
+// checkTemperature checks the temperature of all Shelly sensors and uses the hottest reading.
+// Cancelling the context aborts in-flight reads.
+// It returns the read error if no sensor could be read; failing to save history or state is only logged.
+// A check started while another one is still running is skipped with errCheckInProgress.
+// All log lines of a check share a correlation ID.
+func (hm *HeatingManager) checkTemperature(ctx context.Context, shellyURLs []string) error {
+	ctx = withCorrelationID(ctx)
+	if !hm.checkMu.TryLock() {
+		slog.WarnContext(ctx, "Skipping temperature check, the previous check is still running")
+		return errCheckInProgress
+	}
+	defer hm.checkMu.Unlock()
+
+	config := hm.currentConfig()
+	temperature, source, err := hm.readTemperature(ctx, shellyURLs)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get temperature: %v", err)
+		temperatureReadFailures.WithLabelValues(hm.zoneName()).Inc()
+		hm.mu.Lock()
+		hm.failedReads++
+		hm.mu.Unlock()
+		slog.ErrorContext(ctx, "Failed to get temperature", "err", err)
+		hm.consecutiveFailures++
+		if hm.consecutiveFailures == config.MaxConsecutiveFailures {
+			hm.notify(ctx, eventReadFailures, errorReason(err, reasonRepeatedFailures))
+		}
+		return err
 	}
-	defer resp.Body.Close()
+	hm.recordTemperature(ctx, config, temperature, source)
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("failed to get temperature: status code %d", resp.StatusCode)
+// recordTemperature evaluates a successful reading from source: it updates the current temperature,
+// enforces the safety cutoff, tracks the threshold and writes history, metrics and state.
+// The caller holds checkMu.
+func (hm *HeatingManager) recordTemperature(ctx context.Context, config Config, temperature float64, source string) {
+	if hm.consecutiveFailures >= config.MaxConsecutiveFailures {
+		slog.InfoContext(ctx, "Temperature readings recovered", "event", eventReadRecovered, "failures", hm.consecutiveFailures)
+		hm.notify(ctx, eventReadRecovered, reasonRepeatedFailures)
 	}
+	hm.consecutiveFailures = 0
+	celsius := config.roundTemperature(config.toCelsius(temperature))
+	temperatureGauge.WithLabelValues(hm.zoneName()).Set(celsius)
+	readTime := hm.Clock.Now()
+	hm.mu.Lock()
+	hm.LastTemperature = temperature
+	hm.LastReadTime = readTime
+	hm.successfulReads++
+	hm.mu.Unlock()
+	hm.recordReading(readTime, celsius)
+
+	// The safety cutoff acts on the instantaneous reading, the threshold on the moving average.
+	hm.enforceSafetyCutoff(ctx, temperature)
+	hm.trackPasteurization(temperature)
+	smoothed := config.roundTemperature(hm.smoothTemperature(temperature))
+	hm.trackCooldown(ctx, readTime, config.roundTemperature(config.toCelsius(smoothed)), config.toCelsius(config.TemperatureThreshold))
 
-	body, err := io.ReadAll(resp.Body)
+	crossedAt, sustained := hm.sustainAboveThreshold(smoothed > config.TemperatureThreshold)
+	switch {
+	case smoothed > config.TemperatureThreshold && !sustained:
+		slog.InfoContext(ctx, "Temperature is above the threshold, waiting for it to stay there", "temperature", temperature, "smoothed", smoothed, "threshold", config.TemperatureThreshold, "unit", config.TemperatureUnit, "since", crossedAt, "sustainMinutes", config.ThresholdSustainMinutes)
+	case smoothed > config.TemperatureThreshold:
+		slog.InfoContext(ctx, "Temperature has exceeded the threshold, legionella heating will be rescheduled", "temperature", temperature, "smoothed", smoothed, "threshold", config.TemperatureThreshold, "unit", config.TemperatureUnit)
+		hm.setTemperatureExceeded(true)
+	case config.ThresholdHysteresis > 0 && smoothed < config.TemperatureThreshold-config.ThresholdHysteresis:
+		if hm.isTemperatureExceeded() {
+			slog.InfoContext(ctx, "Temperature dropped below the hysteresis band, threshold flag reset", "temperature", temperature, "smoothed", smoothed, "threshold", config.TemperatureThreshold, "hysteresis", config.ThresholdHysteresis, "unit", config.TemperatureUnit)
+			hm.setTemperatureExceeded(false)
+		}
+		slog.InfoContext(ctx, "Temperature is OK", "temperature", temperature, "smoothed", smoothed, "threshold", config.TemperatureThreshold, "unit", config.TemperatureUnit)
+	default:
+		slog.InfoContext(ctx, "Temperature is OK", "temperature", temperature, "smoothed", smoothed, "threshold", config.TemperatureThreshold, "unit", config.TemperatureUnit)
+	}
+
+	hm.publishMQTTState(ctx, temperature)
+	if err := hm.appendHistory(readTime, celsius, hm.isTemperatureExceeded()); err != nil {
+		slog.WarnContext(ctx, "Failed to write temperature history", "err", err)
+	}
+	if err := hm.writeInflux(ctx, readTime, celsius, source); err != nil {
+		slog.WarnContext(ctx, "Failed to write temperature to InfluxDB", "err", err)
+	}
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save state", "err", err)
+	}
+}
+
+// readTemperature reads the primary sources and falls back to the fallback sensor if none of them could be read.
+// The primary sources are TemperatureSources if set, otherwise the Shelly sensors at shellyURLs.
+// It returns the temperature and whether it came from sourcePrimary or sourceFallback; if both fail, the combined
+// error is returned. All reads including their retries share a deadline of RetryDeadlineFraction of the check
+// interval, so a slow device cannot delay the next check.
+func (hm *HeatingManager) readTemperature(ctx context.Context, shellyURLs []string) (float64, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, hm.readDeadline())
+	defer cancel()
+
+	sources := hm.TemperatureSources
+	if len(sources) == 0 {
+		sources = hm.shellySources(shellyURLs)
+	}
+	temperature, err := hm.readMaxTemperature(ctx, sources)
+	if err == nil {
+		slog.DebugContext(ctx, "Temperature read from primary sensors", "source", sourcePrimary, "temperature", temperature)
+		return temperature, sourcePrimary, nil
+	}
+	config := hm.currentConfig()
+	fallbackURL := config.ShellyTempFallbackURL
+	if fallbackURL == "" {
+		return 0, "", err
+	}
+
+	slog.WarnContext(ctx, "Primary temperature sensors failed, trying fallback", "url", fallbackURL, "err", err)
+	fallbackTemperature, fallbackErr := shellySource{hm: hm, url: fallbackURL}.Read(ctx)
+	if fallbackErr != nil {
+		return 0, "", errors.Join(err, fmt.Errorf("fallback sensor: %w", fallbackErr))
+	}
+	fallbackTemperature = config.roundTemperature(fallbackTemperature)
+	slog.InfoContext(ctx, "Temperature read from fallback sensor", "source", sourceFallback, "url", fallbackURL, "temperature", fallbackTemperature)
+	return fallbackTemperature, sourceFallback, nil
+}
+
+// Temperature sources reported by readTemperature.
+const (
+	sourcePrimary  = "primary"
+	sourceFallback = "fallback"
+)
+
+// readMaxTemperature reads all given sources and returns the highest temperature.
+// It only fails if none of the sources could be read.
+func (hm *HeatingManager) readMaxTemperature(ctx context.Context, sources []TemperatureSource) (float64, error) {
+	config := hm.currentConfig()
+	var (
+		maxTemperature float64
+		readings       int
+		errs           []error
+	)
+	for _, source := range sources {
+		temperature, err := source.Read(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get temperature from sensor", "sensor", sourceName(source), "err", err)
+			errs = append(errs, err)
+			continue
+		}
+		temperature = config.roundTemperature(temperature)
+		slog.DebugContext(ctx, "Sensor temperature", "sensor", sourceName(source), "temperature", temperature, "unit", config.TemperatureUnit)
+		if readings == 0 || temperature > maxTemperature {
+			maxTemperature = temperature
+		}
+		readings++
+	}
+
+	if readings == 0 {
+		if len(errs) == 0 {
+			return 0, fmt.Errorf("no temperature sensors configured")
+		}
+		return 0, errors.Join(errs...)
+	}
+	return maxTemperature, nil
+}
+
+// getTemperature gets the temperature of a Shelly device.
+// Failed requests are retried with exponential backoff capped at MaxBackoffSeconds; each attempt is bounded
+// by the HTTP client timeout. Retries stop early if the backoff would outlast the context deadline.
+// Cancelling the context aborts the request and any pending retry.
+// The calibration offset of the sensor is added to the reading.
+func (hm *HeatingManager) getTemperature(ctx context.Context, shellyTempURL string) (float64, error) {
+	config := hm.currentConfig()
+	body, contentType, err := hm.fetchTemperature(ctx, shellyTempURL)
+	backoff := time.Duration(config.RetryBackoff) * time.Millisecond
+	maxBackoff := time.Duration(config.MaxBackoffSeconds) * time.Second
+	for retry := 0; err != nil && retryable(err) && retry < config.MaxRetries && ctx.Err() == nil; retry++ {
+		backoff = min(backoff, maxBackoff)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+			slog.WarnContext(ctx, "Temperature read failed, no time left for retries", "deadline", deadline, "err", err)
+			break
+		}
+		slog.WarnContext(ctx, "Temperature read failed, retrying", "backoff", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		body, contentType, err = hm.fetchTemperature(ctx, shellyTempURL)
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to read response body: %v", err)
+		return 0, err
 	}
 
-	var tempResponse TempResponse
-	if err := json.Unmarshal(body, &tempResponse); err != nil {
-		return 0, fmt.Errorf("failed to unmarshal temperature response: %v", err)
+	temperature, err := hm.parseTemperature(body)
+	if err != nil {
+		return 0, fmt.Errorf("%w (content type %q, body %q)", err, contentType, responseSnippet(body))
 	}
+	return temperature + config.temperatureOffset(shellyTempURL), nil
+}
 
-	return tempResponse.TC, nil
+// responseSnippet returns the start of a response body for error messages,
+// truncated to responseSnippetLength bytes.
+func responseSnippet(body []byte) string {
+	if len(body) <= responseSnippetLength {
+		return string(body)
+	}
+	return string(body[:responseSnippetLength]) + "..."
 }
 
-// weeklyCheck checks if the temperature threshold has been exceeded and turns on the Shelly heating if necessary.
-func (hm *HeatingManager) weeklyCheck(shellyHeatingOnURL string, shellyHeatingOffURL string) {
-	if !hm.TemperatureExceeded {
-		if err := hm.turnShellyOn(shellyHeatingOnURL, shellyHeatingOffURL); err != nil {
-			log.Printf("Failed to turn on Shelly: %v", err)
+// parseTemperature extracts the temperature in the configured unit from a Shelly response body.
+// It accepts a TempResponse object, nested in an RPC result for Gen2 devices, or a bare number.
+// With TempJSONPath set, the number at that path is used as is instead.
+func (hm *HeatingManager) parseTemperature(body []byte) (float64, error) {
+	config := hm.currentConfig()
+	if config.TempJSONPath != "" {
+		temperature, err := extractJSONNumber(body, config.TempJSONPath)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrParse, err)
+		}
+		return temperature, nil
+	}
+	if config.ShellyGeneration == shellyGen2 {
+		var rpcResponse RPCTempResponse
+		// Plain HTTP GET calls to /rpc return the result without the RPC envelope.
+		if err := json.Unmarshal(body, &rpcResponse); err == nil && rpcResponse.Result != nil {
+			return hm.temperatureInUnit(*rpcResponse.Result), nil
+		}
+	}
+
+	var tempResponse TempResponse
+	jsonErr := json.Unmarshal(body, &tempResponse)
+	if jsonErr == nil {
+		return hm.temperatureInUnit(tempResponse), nil
+	}
+
+	temperature, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+	if err != nil {
+		// The body is quoted by getTemperature, truncated; NumError would repeat it in full.
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
 		}
+		return 0, fmt.Errorf("failed to parse temperature response: %w: neither a temperature object (%v) nor a number (%v)", ErrParse, jsonErr, err)
 	}
-	hm.TemperatureExceeded = false
-	hm.saveLastCheckTime()
+	return temperature, nil
 }
 
-// turnShellyOn turns on the Shelly heating, schedules it to turn off after 4 hours, and checks if the temperature exceeds 60°C.
-func (hm *HeatingManager) turnShellyOn(shellyHeatingOnURL, shellyHeatingOffURL string) error {
-	resp, err := http.Get(shellyHeatingOnURL)
+// temperatureInUnit returns the reading of a response in the configured unit.
+func (hm *HeatingManager) temperatureInUnit(response TempResponse) float64 {
+	config := hm.currentConfig()
+	if config.TemperatureUnit == unitFahrenheit {
+		return response.TF
+	}
+	return response.TC
+}
+
+// fetchTemperature performs a single temperature request and returns the response body and its content type.
+// Bodies larger than MaxResponseBytes are rejected without being read completely,
+// empty or whitespace-only bodies with ErrEmptyResponse.
+func (hm *HeatingManager) fetchTemperature(ctx context.Context, shellyTempURL string) ([]byte, string, error) {
+	maxBytes := int64(hm.currentConfig().MaxResponseBytes)
+	resp, err := hm.shellyGet(ctx, shellyTempURL)
 	if err != nil {
-		return fmt.Errorf("failed to turn on Shelly: %v", err)
+		return nil, "", fmt.Errorf("failed to get temperature: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to turn on Shelly: status code %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("failed to get temperature: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %v", err)
 	}
+	if int64(len(body)) > maxBytes {
+		return nil, "", fmt.Errorf("failed to read response body: larger than %d bytes", maxBytes)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, "", fmt.Errorf("failed to get temperature: %w", ErrEmptyResponse)
+	}
+
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// weeklyCheck checks if the temperature threshold has been exceeded, or with PasteurizationTemp set whether
+// the tank was pasteurized long enough, and turns on the Shelly heating if necessary.
+// Scheduled and manually triggered checks are serialized. The threshold flag is reset and the check time
+// saved only if the check succeeded, so a failed heating run is retried instead of silently skipped.
+// While the battery is low, the heating is deferred with errHeatingDeferred for up to MaxBatteryDeferralHours.
+// A pending manual skip is consumed, skipping the heating.
+// With force set, the minimum heating interval, the battery and a pending manual skip are ignored.
+// All log lines of the check and the heating cycle it starts share a correlation ID.
+func (hm *HeatingManager) weeklyCheck(ctx context.Context, controller HeatingController, force bool) error {
+	ctx = withCorrelationID(ctx)
+	hm.weeklyMu.Lock()
+	defer hm.weeklyMu.Unlock()
 
-	fmt.Println("Shelly turned on.")
+	hm.mu.Lock()
+	failedAt := hm.heatingFailedAt
+	hm.mu.Unlock()
+	if !failedAt.IsZero() {
+		slog.WarnContext(ctx, "The previous weekly heating failed, this check makes up for it", "failedAt", failedAt)
+	}
 
-	// Schedule to turn off after 4 hours
-	offTimer := time.AfterFunc(4*time.Hour, func() {
-		if err := hm.turnShellyOff(shellyHeatingOffURL); err != nil {
-			log.Printf("Failed to turn off Shelly: %v", err)
+	skipReason := hm.weeklyHeatingSkipReason()
+	if !force && hm.isSkipNextWeekly() {
+		slog.InfoContext(ctx, "Skipping weekly heating due to manual override", "event", eventHeatingSkipped)
+		skipReason = reasonManualSkip
+	}
+	if skipReason == "" {
+		if !force && hm.deferForBattery(ctx) {
+			return errHeatingDeferred
+		}
+		err := hm.turnHeatingOn(ctx, controller, force)
+		switch {
+		case errors.Is(err, errHeatingTooSoon):
+			hm.recordWeeklyOutcome(ctx, outcomeSkipped, reasonMinHeatingInterval, nil)
+			hm.notify(ctx, eventHeatingSkipped, reasonMinHeatingInterval)
+		case errors.Is(err, errHeatingInProgress):
+			hm.recordWeeklyOutcome(ctx, outcomeSkipped, reasonHeatingInProgress, nil)
+			hm.notify(ctx, eventHeatingSkipped, reasonHeatingInProgress)
+		case err != nil:
+			hm.recordWeeklyOutcome(ctx, outcomeFailed, reasonWeeklyLegionella, err)
+			hm.recordHeatingFailure(ctx, err)
+			if saveErr := hm.saveState(); saveErr != nil {
+				slog.ErrorContext(ctx, "Failed to save state", "err", saveErr)
+			}
+			return err
+		default:
+			hm.recordWeeklyOutcome(ctx, outcomeHeated, reasonWeeklyLegionella, nil)
+			hm.notify(ctx, eventHeatingOn, reasonWeeklyLegionella)
+			hm.scheduleVerification(ctx)
 		}
-		fmt.Println("Shelly turned off.")
-		hm.TemperatureExceeded = false
-	})
+	} else {
+		hm.recordWeeklyOutcome(ctx, outcomeSkipped, skipReason, nil)
+		hm.notify(ctx, eventHeatingSkipped, skipReason)
+	}
+	hm.setTemperatureExceeded(false)
+	hm.resetPasteurization()
+	hm.resetBatteryDeferral()
+	hm.mu.Lock()
+	hm.heatingFailedAt = time.Time{}
+	if skipReason == reasonManualSkip {
+		hm.skipNextWeekly = false
+	}
+	hm.mu.Unlock()
+	hm.saveLastCheckTime(ctx)
+	return nil
+}
+
+// recordHeatingFailure records that the weekly heating could not be turned on, so the retried check knows
+// the previous one did not happen. The first failure since the last completed weekly check sends a critical
+// notification; the retries only log.
+func (hm *HeatingManager) recordHeatingFailure(ctx context.Context, err error) {
+	hm.mu.Lock()
+	first := hm.heatingFailedAt.IsZero()
+	if first {
+		hm.heatingFailedAt = hm.Clock.Now()
+	}
+	failedAt := hm.heatingFailedAt
+	hm.mu.Unlock()
+
+	slog.ErrorContext(ctx, "Failed to turn on the weekly heating", "event", eventHeatingFailed, "failedSince", failedAt, "err", err)
+	if first {
+		hm.notify(ctx, eventHeatingFailed, errorReason(err, reasonWeeklyLegionella))
+	}
+}
+
+// turnHeatingOn turns on the heating and schedules it to turn off after the configured heating duration,
+// or earlier once the temperature exceeds the turn-off temperature.
+// The context only bounds the on call; the heating cycle is supervised until cancelHeatingOff.
+// Unless force is set, it refuses with errHeatingTooSoon within MinHeatingIntervalHours of the last run.
+// Only one heating run is started at a time: while another call is switching the heating on or its
+// cycle is still running, it refuses with errHeatingInProgress, even with force set.
+func (hm *HeatingManager) turnHeatingOn(ctx context.Context, controller HeatingController, force bool) error {
+	if !hm.heatingMu.TryLock() {
+		slog.InfoContext(ctx, "Skipping heating run, another run is being started", "event", eventHeatingSkipped)
+		return errHeatingInProgress
+	}
+	defer hm.heatingMu.Unlock()
+	hm.mu.Lock()
+	running := hm.cancelHeating != nil
+	hm.mu.Unlock()
+	if running {
+		slog.InfoContext(ctx, "Skipping heating run, the heating cycle is still running", "event", eventHeatingSkipped)
+		return errHeatingInProgress
+	}
 
-	// Check temperature every minute to see if it exceeds 60°C
-	checkTimer := time.NewTicker(5 * time.Minute)
+	if lastHeatingRun, minInterval, tooSoon := hm.heatingTooSoon(); !force && tooSoon {
+		slog.InfoContext(ctx, "Skipping heating run, the previous run was too recent", "event", eventHeatingSkipped, "lastHeatingRun", lastHeatingRun, "minInterval", minInterval)
+		return errHeatingTooSoon
+	}
+
+	if err := hm.switchHeatingOn(ctx, controller); err != nil {
+		return err
+	}
+
+	config := hm.currentConfig()
+	offAt := hm.Clock.Now().Add(time.Duration(config.HeatingDurationMinutes) * time.Minute)
+	superviseCtx := hm.startHeatingSupervision(ctx, controller, offAt)
+	if config.ShellyPowerURL != "" && !config.DryRun {
+		hm.heatingWG.Add(1)
+		go func() {
+			defer hm.heatingWG.Done()
+			hm.confirmHeatingPower(superviseCtx, config.ShellyPowerURL)
+		}()
+	}
+	return nil
+}
+
+// heatingTooSoon reports whether the last heating run started less than MinHeatingIntervalHours ago,
+// along with that run and the interval.
+func (hm *HeatingManager) heatingTooSoon() (time.Time, time.Duration, bool) {
+	minInterval := time.Duration(hm.currentConfig().MinHeatingIntervalHours) * time.Hour
+	hm.mu.Lock()
+	lastHeatingRun := hm.lastHeatingRun
+	hm.mu.Unlock()
+	return lastHeatingRun, minInterval, minInterval > 0 && hm.Clock.Now().Sub(lastHeatingRun) < minInterval
+}
+
+// startHeatingSupervision persists offAt as the end of the heating cycle and supervises the cycle
+// until then. It returns the context of the supervision, which ends once the heating is off.
+func (hm *HeatingManager) startHeatingSupervision(ctx context.Context, controller HeatingController, offAt time.Time) context.Context {
+	superviseCtx, cancel := context.WithCancel(detach(ctx))
+	hm.mu.Lock()
+	hm.cancelHeating = cancel
+	hm.heatingOffAt = offAt
+	hm.mu.Unlock()
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save heating off time", "err", err)
+	}
+
+	hm.heatingWG.Add(1)
 	go func() {
-		for range checkTimer.C {
-			temp, err := getTemperature(hm.Config.ShellyURL)
+		defer hm.heatingWG.Done()
+		hm.superviseHeating(superviseCtx, controller, offAt)
+		// Ends the power check once the heating is off.
+		cancel()
+	}()
+	return superviseCtx
+}
+
+// resumeHeatingOff takes over a heating cycle interrupted by a restart, using the persisted off time:
+// an off time in the past turns the heating off right away, one in the future is supervised again.
+// If turning the heating off fails, the off time is kept and retryHeatingOff tries again.
+func (hm *HeatingManager) resumeHeatingOff(ctx context.Context) {
+	ctx = withCorrelationID(ctx)
+	hm.heatingMu.Lock()
+	defer hm.heatingMu.Unlock()
+	hm.mu.Lock()
+	offAt := hm.heatingOffAt
+	running := hm.cancelHeating != nil
+	hm.mu.Unlock()
+	if offAt.IsZero() || running {
+		return
+	}
+
+	config := hm.currentConfig()
+	controller := hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)
+	if !hm.Clock.Now().Before(offAt) {
+		slog.WarnContext(ctx, "Heating cycle has ended but the heating was not turned off, turning it off", "offAt", offAt)
+		hm.turnHeatingOff(ctx, controller)
+		return
+	}
+	slog.InfoContext(ctx, "Resuming the interrupted heating cycle", "offAt", offAt)
+	hm.mu.Lock()
+	hm.heatingOn = true
+	hm.mu.Unlock()
+	hm.startHeatingSupervision(ctx, controller, offAt)
+}
+
+// retryHeatingOff turns off the heating of a cycle that has ended while its off call failed, e.g. because
+// the relay was unreachable after a power cut. The temperature monitoring calls it before every check.
+func (hm *HeatingManager) retryHeatingOff(ctx context.Context) {
+	hm.mu.Lock()
+	pending := !hm.heatingOffAt.IsZero() && hm.cancelHeating == nil && !hm.Clock.Now().Before(hm.heatingOffAt)
+	hm.mu.Unlock()
+	if pending {
+		hm.resumeHeatingOff(ctx)
+	}
+}
+
+// superviseHeating turns the heating off at offAt or once the temperature exceeds the turn-off
+// temperature, whichever comes first.
+// Cancelling the context abandons the pending off call.
+func (hm *HeatingManager) superviseHeating(ctx context.Context, controller HeatingController, offAt time.Time) {
+	config := hm.currentConfig()
+	offTimer := time.NewTimer(offAt.Sub(hm.Clock.Now()))
+	defer offTimer.Stop()
+
+	checkTicker := time.NewTicker(heatingCheckInterval)
+	defer checkTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Scheduled heating off call cancelled")
+			return
+		case <-offTimer.C:
+			hm.turnHeatingOff(ctx, controller)
+			hm.endHeatingSupervision(ctx)
+			return
+		case <-checkTicker.C:
+			temp, _, err := hm.readTemperature(ctx, config.ShellyURLs)
 			if err != nil {
-				log.Printf("Error checking temperature: %v", err)
+				slog.WarnContext(ctx, "Error checking temperature while heating", "err", err)
 				continue
 			}
-			if temp > hm.Config.TemperatureTurnOff {
-				fmt.Println("Temperature exceeded. Turning off Shelly.")
-				checkTimer.Stop()
-				offTimer.Stop()
-				hm.TemperatureExceeded = false
+			if temp > config.TemperatureTurnOff {
+				slog.InfoContext(ctx, "Turn-off temperature exceeded, turning off heating", "temperature", temp, "threshold", config.TemperatureTurnOff, "unit", config.TemperatureUnit)
+				hm.turnHeatingOff(ctx, controller)
+				hm.endHeatingSupervision(ctx)
+				return
 			}
 		}
-	}()
-	return nil
+	}
 }
 
-// turnShellyOff turns off the Shelly heating.
-func (hm *HeatingManager) turnShellyOff(shellyHeatingOffURL string) error {
-	resp, err := http.Get(shellyHeatingOffURL)
+// turnHeatingOff turns off the heating, retrying once if the first attempt fails, and resets the threshold
+// flag and the pasteurized time reached by the run. If both attempts fail, the off time of the cycle is kept
+// for retryHeatingOff and a notification is sent, once until the heating is off.
+func (hm *HeatingManager) turnHeatingOff(ctx context.Context, controller HeatingController) {
+	err := hm.switchHeatingOff(ctx, controller)
 	if err != nil {
-		return fmt.Errorf("failed to turn off Shelly: %v", err)
+		slog.WarnContext(ctx, "Failed to turn off heating, retrying", "backoff", heatingOffRetryDelay, "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(heatingOffRetryDelay):
+		}
+		err = hm.switchHeatingOff(ctx, controller)
 	}
-	defer resp.Body.Close()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to turn off heating, retrying at the next temperature check", "event", eventHeatingOffFailed, "err", err)
+		hm.mu.Lock()
+		notified := hm.heatingOffFailed
+		hm.heatingOffFailed = true
+		hm.mu.Unlock()
+		if !notified {
+			hm.notify(ctx, eventHeatingOffFailed, errorReason(err, reasonHeatingCycleEnded))
+		}
+	}
+	// The run's own time at temperature must not skip the next weekly heating.
+	hm.setTemperatureExceeded(false)
+	hm.resetPasteurization()
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save state", "err", err)
+	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to turn off Shelly: status code %d", resp.StatusCode)
+// endHeatingSupervision forgets a finished heating cycle unless a newer cycle has replaced it.
+// Replacing a cycle cancels its context while holding mu.
+func (hm *HeatingManager) endHeatingSupervision(ctx context.Context) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if ctx.Err() == nil {
+		hm.cancelHeating = nil
 	}
+}
 
-	fmt.Println("Shelly turned off.")
+// cancelHeatingOff cancels the supervision of a running heating cycle and its pending verification, if any.
+func (hm *HeatingManager) cancelHeatingOff() {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if hm.cancelHeating != nil {
+		hm.cancelHeating()
+		hm.cancelHeating = nil
+	}
+	if hm.cancelVerify != nil {
+		hm.cancelVerify()
+		hm.cancelVerify = nil
+	}
+}
+
+// switchHeatingOn turns on the heating without scheduling it to turn off and records the heating run.
+// Failed attempts are retried; the outcome runs OnHeatingCommand or OnHeatingFailCommand once.
+func (hm *HeatingManager) switchHeatingOn(ctx context.Context, controller HeatingController) error {
+	config := hm.currentConfig()
+	if config.DryRun {
+		slog.InfoContext(ctx, "[DRY-RUN] Would turn on heating", "event", eventHeatingOn, "controller", controllerName(controller))
+		return nil
+	}
+	if err := hm.retryHeatingOn(ctx, controller); err != nil {
+		hm.runHook(ctx, config.OnHeatingFailCommand, eventHeatingOn, err)
+		return err
+	}
+
+	heatingActivations.WithLabelValues(hm.zoneName()).Inc()
+	hm.mu.Lock()
+	hm.lastHeatingRun = hm.Clock.Now()
+	hm.heatingOn = true
+	hm.heatingActivations++
+	hm.mu.Unlock()
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save state", "err", err)
+	}
+	hm.runHook(ctx, config.OnHeatingCommand, eventHeatingOn, nil)
+	return nil
+}
+
+// retryHeatingOn switches the heating on, retrying failed attempts with exponential backoff like temperature
+// reads, up to MaxRetries times with RetryBackoff capped at MaxBackoffSeconds. A relay that accepted the
+// command but did not report on is not retried, since the relay confirmation already waited for it.
+func (hm *HeatingManager) retryHeatingOn(ctx context.Context, controller HeatingController) error {
+	config := hm.currentConfig()
+	err := controller.On(ctx)
+	backoff := time.Duration(config.RetryBackoff) * time.Millisecond
+	maxBackoff := time.Duration(config.MaxBackoffSeconds) * time.Second
+	for retry := 0; err != nil && retryable(err) && !errors.Is(err, errRelayUnconfirmed) && retry < config.MaxRetries; retry++ {
+		backoff = min(backoff, maxBackoff)
+		slog.WarnContext(ctx, "Turning on the heating failed, retrying", "backoff", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		err = controller.On(ctx)
+	}
+	return err
+}
+
+// switchHeatingOff turns off the heating. The outcome runs OnHeatingCommand or OnHeatingFailCommand.
+func (hm *HeatingManager) switchHeatingOff(ctx context.Context, controller HeatingController) error {
+	config := hm.currentConfig()
+	if config.DryRun {
+		slog.InfoContext(ctx, "[DRY-RUN] Would turn off heating", "event", eventHeatingOff, "controller", controllerName(controller))
+		hm.clearHeatingOffAt(ctx)
+		return nil
+	}
+	if err := controller.Off(ctx); err != nil {
+		hm.runHook(ctx, config.OnHeatingFailCommand, eventHeatingOff, err)
+		return err
+	}
+
+	hm.mu.Lock()
+	hm.heatingOn = false
+	hm.heatingOffFailed = false
+	hm.mu.Unlock()
+	hm.clearHeatingOffAt(ctx)
+	hm.runHook(ctx, config.OnHeatingCommand, eventHeatingOff, nil)
 	return nil
 }
 
-// saveLastCheckTime saves the last check time to a file.
-func (hm *HeatingManager) saveLastCheckTime() {
-	now := time.Now()
-	err := os.WriteFile(hm.LastCheckFile, []byte(now.Format(time.RFC3339)), 0644)
+// clearHeatingOffAt forgets the scheduled end of the heating cycle once the heating is off,
+// so a restart does not take over the finished cycle.
+func (hm *HeatingManager) clearHeatingOffAt(ctx context.Context) {
+	hm.mu.Lock()
+	pending := !hm.heatingOffAt.IsZero()
+	hm.heatingOffAt = time.Time{}
+	hm.mu.Unlock()
+	if !pending {
+		return
+	}
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save state", "err", err)
+	}
+}
+
+// saveLastCheckTime records the current time as the last check time and persists it.
+func (hm *HeatingManager) saveLastCheckTime(ctx context.Context) {
+	hm.mu.Lock()
+	hm.lastCheck = hm.Clock.Now()
+	hm.mu.Unlock()
+
+	if err := hm.saveState(); err != nil {
+		slog.ErrorContext(ctx, "Failed to save last check time", "err", err)
+	}
+}
+
+// NextWeeklyCheck returns the time of the next weekly check.
+// With a cron expression the check runs at its first time after the last check, with a configured
+// weekday at the next occurrence of that weekday and hour, otherwise WeeklyCheckInterval hours
+// after the last check. An overdue check returns a time in the past. The time is zero if no check
+// has been recorded yet, meaning the first check is pending.
+func (hm *HeatingManager) NextWeeklyCheck() time.Time {
+	config := hm.currentConfig()
+	lastCheck, err := hm.readLastCheckTime()
 	if err != nil {
-		log.Printf("Failed to save last check time: %v", err)
+		return time.Time{}
 	}
+
+	if config.WeeklyCheckCron != "" {
+		schedule, _ := parseWeeklyCron(config.WeeklyCheckCron)
+		return schedule.Next(lastCheck.In(config.location()))
+	}
+
+	if config.WeeklyCheckWeekday != "" {
+		weekday, _ := parseWeekday(config.WeeklyCheckWeekday)
+		nextCheck := nextWeekdayHour(hm.Clock.Now().In(config.location()), weekday, config.WeeklyCheckHour)
+		// The previous occurrence is still due if it was missed.
+		if previous := nextCheck.AddDate(0, 0, -7); lastCheck.Before(previous) {
+			return previous
+		}
+		return nextCheck
+	}
+
+	return lastCheck.Add(time.Duration(config.WeeklyCheckInterval) * time.Hour)
 }
 
 // nextWeeklyCheckDuration calculates the duration until the next weekly check.
+// An overdue check runs immediately; a pending first check waits until StartupGracePeriodMinutes after startup.
 func (hm *HeatingManager) nextWeeklyCheckDuration() time.Duration {
-	lastCheck, err := hm.readLastCheckTime()
-	if err != nil {
-		return 0
+	nextCheck := hm.NextWeeklyCheck()
+	if nextCheck.IsZero() {
+		grace := time.Duration(hm.currentConfig().StartupGracePeriodMinutes) * time.Minute
+		if grace == 0 {
+			return 0
+		}
+		hm.mu.Lock()
+		nextCheck = hm.startTime.Add(grace)
+		hm.mu.Unlock()
 	}
-	nextCheck := lastCheck.Add(time.Duration(hm.Config.WeeklyCheckInterval) * time.Hour)
-	if time.Now().After(nextCheck) {
-		return 0
+	if d := nextCheck.Sub(hm.Clock.Now()); d > 0 {
+		return d
 	}
-	return time.Until(nextCheck)
+	return 0
 }
 
-// readLastCheckTime reads the last check time from a file.
-func (hm *HeatingManager) readLastCheckTime() (time.Time, error) {
-	data, err := os.ReadFile(hm.LastCheckFile)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to read last check time: %w", err)
+// formatNextWeeklyCheck formats the next weekly check time for display, "pending" if no check has run yet.
+func formatNextWeeklyCheck(nextCheck time.Time) string {
+	if nextCheck.IsZero() {
+		return "pending"
 	}
+	return nextCheck.Format(time.RFC3339)
+}
 
-	lastCheck, err := time.Parse(time.RFC3339, string(data))
-	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to parse last check time: %w", err)
+// nextWeekdayHour returns the first time after from that falls on the given weekday and hour.
+func nextWeekdayHour(from time.Time, weekday time.Weekday, hour int) time.Time {
+	days := (int(weekday) - int(from.Weekday()) + 7) % 7
+	next := time.Date(from.Year(), from.Month(), from.Day()+days, hour, 0, 0, 0, from.Location())
+	if !next.After(from) {
+		next = next.AddDate(0, 0, 7)
 	}
+	return next
+}
 
-	return lastCheck, nil
+// parseWeekday parses a case-insensitive English weekday name.
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// parseWeeklyCron parses a standard five-field cron expression or a descriptor such as @weekly.
+// The expression is evaluated in the configured time zone unless it starts with CRON_TZ=.
+func parseWeeklyCron(expr string) (cron.Schedule, error) {
+	return cron.ParseStandard(expr)
+}
+
+// readLastCheckTime returns the last check time. It fails if no check has been recorded yet.
+func (hm *HeatingManager) readLastCheckTime() (time.Time, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if hm.lastCheck.IsZero() {
+		return time.Time{}, errors.New("no weekly check recorded")
+	}
+	return hm.lastCheck, nil
 }
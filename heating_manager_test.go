@@ -1,14 +1,86 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+// stubHTTPClient is an HTTPClient returning canned responses.
+type stubHTTPClient struct {
+	do func(req *http.Request) (*http.Response, error)
+}
+
+func (c *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req)
+}
+
+// stubResponse returns an HTTPClient that answers every request with the given status and body.
+func stubResponse(status int, body string) *stubHTTPClient {
+	return &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}}
+}
+
+// fakeClock is a Clock standing still at a settable time.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// testConfig returns a valid config matching the example config.json, without a history file.
+// Its device URLs point to a closed local port, so tests not serving them fail fast.
+func testConfig() Config {
+	return Config{
+		ShellyURL:               "http://127.0.0.1:1/rpc/Temperature.GetStatus?id=102",
+		ShellyHeatingOnURL:      "http://127.0.0.1:1/rpc/Switch.Set?id=0&on=true",
+		ShellyHeatingOffURL:     "http://127.0.0.1:1/rpc/Switch.Set?id=0&on=false",
+		TemperatureThreshold:    55,
+		TemperatureTurnOff:      60,
+		MaxSafeTemperature:      85,
+		CheckInterval:           5,
+		WeeklyCheckInterval:     168,
+		PVSurplusThresholdWatts: 2000,
+		MinBatterySOC:           30,
+	}
+}
+
+// newTestManager creates a HeatingManager from testConfig that keeps all its files in a temporary directory.
+func newTestManager(t *testing.T) *HeatingManager {
+	t.Helper()
+	manager, err := NewHeatingManagerWithConfig(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to create HeatingManager: %v", err)
+	}
+	dir := t.TempDir()
+	manager.LastCheckFile = filepath.Join(dir, "lastCheck.txt")
+	manager.StateFile = filepath.Join(dir, "state.json")
+	manager.restoreState()
+	return manager
+}
+
 func TestNewHeatingManager(t *testing.T) {
-	manager, err := NewHeatingManager()
+	manager, err := NewHeatingManager("config.json")
 	if err != nil {
 		t.Fatalf("Failed to create HeatingManager: %v", err)
 	}
@@ -17,6 +89,25 @@ func TestNewHeatingManager(t *testing.T) {
 	}
 }
 
+func TestNewHeatingManagerWithConfig(t *testing.T) {
+	manager, err := NewHeatingManagerWithConfig(testConfig())
+	if err != nil {
+		t.Fatalf("Failed to create HeatingManager: %v", err)
+	}
+	if manager.Config.HTTPTimeout != defaultHTTPTimeout {
+		t.Errorf("Expected the default HTTP timeout %d, got %d", defaultHTTPTimeout, manager.Config.HTTPTimeout)
+	}
+	if manager.checkInterval() != 5*time.Minute {
+		t.Errorf("Expected a check interval of 5 minutes, got %v", manager.checkInterval())
+	}
+
+	config := testConfig()
+	config.ShellyHeatingOnURL = ""
+	if _, err := NewHeatingManagerWithConfig(config); err == nil {
+		t.Error("Expected an error for an invalid config")
+	}
+}
+
 func TestCheckTemperature(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -24,18 +115,118 @@ func TestCheckTemperature(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	manager, _ := NewHeatingManager()
-	manager.Config.ShellyURL = ts.URL
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{ts.URL}
+	manager.Config.TemperatureThreshold = 55
 
-	manager.checkTemperature(manager.Config.ShellyURL)
+	manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
 	if manager.TemperatureExceeded {
 		t.Error("TemperatureExceeded should be false for temperature 25")
 	}
 }
 
+func TestCheckTemperatureExceeded(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("58"))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{ts.URL}
+	manager.Config.TemperatureThreshold = 55
+	manager.Config.MaxSafeTemperature = 0
+
+	if err := manager.checkTemperature(context.Background(), manager.Config.ShellyURLs); err != nil {
+		t.Fatalf("checkTemperature returned an error: %v", err)
+	}
+	if !manager.TemperatureExceeded {
+		t.Error("TemperatureExceeded should be true for temperature 58 above the threshold of 55")
+	}
+	if manager.LastTemperature != 58 {
+		t.Errorf("Expected last temperature 58, got %v", manager.LastTemperature)
+	}
+}
+
 func TestWeeklyCheck(t *testing.T) {
-	manager, _ := NewHeatingManager()
-	manager.weeklyCheck("someURL", "someOtherURL")
+	var onCalls, offCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/on":
+			onCalls.Add(1)
+		case "/off":
+			offCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.HeatingDurationMinutes = 0
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	defer manager.cancelHeatingOff()
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected heating to be turned on once, got %d", onCalls.Load())
+	}
+	if _, err := manager.readLastCheckTime(); err != nil {
+		t.Errorf("Expected last check time to be saved: %v", err)
+	}
+
+	// The off call is scheduled with the heating duration of 0 minutes.
+	deadline := time.Now().Add(time.Second)
+	for offCalls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if offCalls.Load() != 1 {
+		t.Errorf("Expected heating to be turned off once, got %d", offCalls.Load())
+	}
+	manager.heatingWG.Wait()
+}
+
+func TestWeeklyCheckFailureKeepsState(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err == nil {
+		t.Fatal("Expected weeklyCheck to fail when the Shelly cannot be turned on")
+	}
+	if _, err := manager.readLastCheckTime(); err == nil {
+		t.Error("Expected no last check time after a failed check")
+	}
+	if manager.nextWeeklyCheckDuration() != 0 {
+		t.Error("Expected the failed check to stay due")
+	}
+}
+
+func TestNextWeeklyCheckDurationStartupGracePeriod(t *testing.T) {
+	start := time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.startTime = start
+	manager.Config.StartupGracePeriodMinutes = 30
+
+	if d := manager.nextWeeklyCheckDuration(); d != 30*time.Minute {
+		t.Errorf("Expected the first check to wait 30 minutes, got %v", d)
+	}
+	clock.Advance(45 * time.Minute)
+	if d := manager.nextWeeklyCheckDuration(); d != 0 {
+		t.Errorf("Expected the first check to be due after the grace period, got %v", d)
+	}
+
+	// A recorded check is unaffected by the grace period.
+	clock.now = start
+	manager.lastCheck = start.Add(-200 * time.Hour)
+	if d := manager.nextWeeklyCheckDuration(); d != 0 {
+		t.Errorf("Expected the overdue check to run immediately, got %v", d)
+	}
 }
 
 func TestGetTemperature(t *testing.T) {
@@ -46,7 +237,8 @@ func TestGetTemperature(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	temp, err := getTemperature(ts.URL)
+	manager := newTestManager(t)
+	temp, err := manager.getTemperature(context.Background(), ts.URL)
 	if err != nil {
 		t.Errorf("getTemperature returned an error: %v", err)
 	}
@@ -54,3 +246,1120 @@ func TestGetTemperature(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expectedTemp, temp)
 	}
 }
+
+func TestGetTemperatureTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.HTTPClient = &http.Client{Timeout: 50 * time.Millisecond}
+	manager.Config.RetryBackoff = 1
+
+	if _, err := manager.getTemperature(context.Background(), ts.URL); err == nil {
+		t.Error("Expected getTemperature to fail on timeout")
+	}
+}
+
+func TestGetTemperatureRetry(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id":100,"tC":42.5,"tF":108.5}`))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+
+	temp, err := manager.getTemperature(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatalf("getTemperature returned an error: %v", err)
+	}
+	if temp != 42.5 {
+		t.Errorf("Expected 42.5, got %v", temp)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestGetTemperatureRetriesExhausted(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.MaxRetries = 2
+	manager.Config.RetryBackoff = 1
+
+	if _, err := manager.getTemperature(context.Background(), ts.URL); err == nil {
+		t.Error("Expected getTemperature to fail after all retries")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestParseTemperatureGen2(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.ShellyGeneration = shellyGen2
+
+	tests := map[string]string{
+		"rpc envelope": `{"id":1,"src":"shellyplus1","result":{"id":0,"tC":61.2,"tF":142.2}}`,
+		"plain result": `{"id":0,"tC":61.2,"tF":142.2}`,
+	}
+	for name, body := range tests {
+		temp, err := manager.parseTemperature([]byte(body))
+		if err != nil {
+			t.Errorf("%s: parseTemperature returned an error: %v", name, err)
+		}
+		if temp != 61.2 {
+			t.Errorf("%s: expected 61.2, got %v", name, temp)
+		}
+	}
+}
+
+func TestParseTemperatureFormats(t *testing.T) {
+	manager := newTestManager(t)
+
+	tests := map[string]string{
+		"json object":  `{"id":100,"tC":48.5,"tF":119.3}`,
+		"bare number":  "48.5",
+		"with newline": "48.5\n",
+	}
+	for name, body := range tests {
+		temp, err := manager.parseTemperature([]byte(body))
+		if err != nil {
+			t.Errorf("%s: parseTemperature returned an error: %v", name, err)
+		}
+		if temp != 48.5 {
+			t.Errorf("%s: expected 48.5, got %v", name, temp)
+		}
+	}
+
+	if _, err := manager.parseTemperature([]byte("offline")); err == nil {
+		t.Error("Expected an error for a body that is neither JSON nor a number")
+	}
+}
+
+func TestStartTemperatureMonitoringStopsOnCancel(t *testing.T) {
+	manager := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		manager.StartTemperatureMonitoring(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("StartTemperatureMonitoring did not return after cancel")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		ShellyURL:            "http://shelly/temp",
+		ShellyHeatingOnURL:   "http://shelly/on",
+		ShellyHeatingOffURL:  "http://shelly/off",
+		TemperatureThreshold: 55,
+		TemperatureTurnOff:   60,
+		CheckInterval:        5,
+		WeeklyCheckInterval:  168,
+	}
+	valid.setDefaults()
+	if err := valid.validate(); err != nil {
+		t.Fatalf("Expected valid config, got error: %v", err)
+	}
+
+	fahrenheit := valid
+	fahrenheit.TemperatureUnit = "F"
+	fahrenheit.TemperatureThreshold = 131
+	fahrenheit.TemperatureTurnOff = 140
+	if err := fahrenheit.validate(); err != nil {
+		t.Errorf("Expected valid Fahrenheit config, got error: %v", err)
+	}
+
+	tests := map[string]func(c *Config){
+		"no temperature URLs":             func(c *Config) { c.ShellyURLs = nil },
+		"empty temperature URL":           func(c *Config) { c.ShellyURLs = []string{"http://shelly/temp", ""} },
+		"empty heating on URL":            func(c *Config) { c.ShellyHeatingOnURL = "" },
+		"empty heating off URL":           func(c *Config) { c.ShellyHeatingOffURL = "" },
+		"zero check interval":             func(c *Config) { c.CheckInterval = 0 },
+		"negative heating interval":       func(c *Config) { c.MinHeatingIntervalHours = -1 },
+		"negative battery SOC":            func(c *Config) { c.MinBatterySOC = -1 },
+		"battery SOC too high":            func(c *Config) { c.MinBatterySOC = 101 },
+		"negative check jitter":           func(c *Config) { c.CheckJitterSeconds = -1 },
+		"jitter exceeds interval":         func(c *Config) { c.CheckJitterSeconds = c.CheckInterval * 60 },
+		"negative weekly interval":        func(c *Config) { c.WeeklyCheckInterval = -1 },
+		"retry deadline too long":         func(c *Config) { c.RetryDeadlineFraction = 1.5 },
+		"active hours start too big":      func(c *Config) { c.ActiveHoursStart = 24 },
+		"negative active hours end":       func(c *Config) { c.ActiveHoursEnd = -1 },
+		"negative inactive interval":      func(c *Config) { c.InactiveCheckInterval = -1 },
+		"negative grace period":           func(c *Config) { c.StartupGracePeriodMinutes = -1 },
+		"threshold too low":               func(c *Config) { c.TemperatureThreshold = -60 },
+		"threshold too high":              func(c *Config) { c.TemperatureThreshold = 200 },
+		"turn off temperature high":       func(c *Config) { c.TemperatureTurnOff = 151 },
+		"unknown weekday":                 func(c *Config) { c.WeeklyCheckWeekday = "Caturday" },
+		"weekly check hour too big":       func(c *Config) { c.WeeklyCheckWeekday = "Sunday"; c.WeeklyCheckHour = 24 },
+		"smtp without recipient":          func(c *Config) { c.SMTPHost = "mail.example.com"; c.EmailFrom = "a@example.com" },
+		"influx without bucket":           func(c *Config) { c.InfluxURL = "http://influxdb:8086" },
+		"unknown severity":                func(c *Config) { c.EmailMinSeverity = "urgent" },
+		"unknown temperature unit":        func(c *Config) { c.TemperatureUnit = "K" },
+		"fahrenheit threshold high":       func(c *Config) { c.TemperatureUnit = "F"; c.TemperatureThreshold = 310 },
+		"max safe temperature high":       func(c *Config) { c.MaxSafeTemperature = 200 },
+		"negative hysteresis":             func(c *Config) { c.ThresholdHysteresis = -1 },
+		"negative sustain minutes":        func(c *Config) { c.ThresholdSustainMinutes = -1 },
+		"temperature precision too high":  func(c *Config) { c.TemperaturePrecision = ptr(7) },
+		"negative temperature precision":  func(c *Config) { c.TemperaturePrecision = ptr(-1) },
+		"negative verify minutes":         func(c *Config) { c.VerifyAfterMinutes = -1 },
+		"relative http base path":         func(c *Config) { c.HTTPBasePath = "pvheat" },
+		"http auth user without password": func(c *Config) { c.HTTPAuthUser = "admin" },
+		"pasteurization no minutes":       func(c *Config) { c.PasteurizationTemp = 60 },
+		"unknown timezone":                func(c *Config) { c.Timezone = "Mars/Olympus_Mons" },
+		"websocket URL not ws":            func(c *Config) { c.ShellyGeneration = shellyGen2; c.ShellyWebSocketURL = "http://shelly/rpc" },
+		"websocket URL on gen1":           func(c *Config) { c.ShellyGeneration = shellyGen1; c.ShellyWebSocketURL = "ws://shelly/rpc" },
+		"websocket URL with auth": func(c *Config) {
+			c.ShellyGeneration = shellyGen2
+			c.ShellyWebSocketURL = "ws://shelly/rpc"
+			c.ShellyUsername = "admin"
+		},
+		"temperature offset too large": func(c *Config) { c.TemperatureOffset = -12 },
+		"sensor offset too large":      func(c *Config) { c.SensorOffsets = map[string]float64{"http://shelly/temp": 15} },
+		"invalid weekly cron":          func(c *Config) { c.WeeklyCheckCron = "every sunday" },
+		"weekly cron never matches":    func(c *Config) { c.WeeklyCheckCron = "0 0 30 2 *" },
+		"weekly cron with weekday":     func(c *Config) { c.WeeklyCheckCron = "@weekly"; c.WeeklyCheckWeekday = "Sunday" },
+		"unknown log level":            func(c *Config) { c.LogLevel = "verbose" },
+		"unknown shelly generation":    func(c *Config) { c.ShellyGeneration = "gen3" },
+		"empty JSON path segment":      func(c *Config) { c.TempJSONPath = "result..tC" },
+	}
+	for name, mutate := range tests {
+		c := valid
+		mutate(&c)
+		if err := c.validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestCheckTemperatureSkipsOverlappingCheck(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{"http://shelly/temp"}
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("42"))}, nil
+	}}
+
+	done := make(chan error)
+	go func() {
+		done <- manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	}()
+	<-started
+	if err := manager.checkTemperature(context.Background(), manager.Config.ShellyURLs); !errors.Is(err, errCheckInProgress) {
+		t.Errorf("Expected errCheckInProgress while a check is running, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Expected the slow check to succeed, got %v", err)
+	}
+}
+
+func TestCheckTemperatureUsesHottestSensor(t *testing.T) {
+	newSensor := func(body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(body))
+		}))
+	}
+	bottom := newSensor(`{"id":100,"tC":40,"tF":104}`)
+	defer bottom.Close()
+	top := newSensor(`{"id":101,"tC":58,"tF":136.4}`)
+	defer top.Close()
+
+	manager := newTestManager(t)
+	manager.Config.TemperatureThreshold = 55
+
+	manager.checkTemperature(context.Background(), []string{bottom.URL, top.URL})
+	if !manager.TemperatureExceeded {
+		t.Error("TemperatureExceeded should be true when the hottest sensor is above the threshold")
+	}
+	if temp, readTime := manager.CurrentTemperature(); temp != 58 || readTime.IsZero() {
+		t.Errorf("Expected current temperature 58 with a read time, got %v at %v", temp, readTime)
+	}
+}
+
+func TestConfigSingleURLCompatibility(t *testing.T) {
+	c := Config{ShellyURL: "http://shelly/temp"}
+	c.setDefaults()
+	if len(c.ShellyURLs) != 1 || c.ShellyURLs[0] != "http://shelly/temp" {
+		t.Errorf("Expected shellyTempURL to become a one-element list, got %v", c.ShellyURLs)
+	}
+}
+
+func TestTurnHeatingOffRetriesOnce(t *testing.T) {
+	attempts := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	oldDelay := heatingOffRetryDelay
+	heatingOffRetryDelay = time.Millisecond
+	defer func() { heatingOffRetryDelay = oldDelay }()
+
+	manager := newTestManager(t)
+	manager.turnHeatingOff(context.Background(), manager.heatingController(ts.URL, ts.URL))
+	if attempts != 2 {
+		t.Errorf("Expected 2 off attempts, got %d", attempts)
+	}
+}
+
+func TestCancelHeatingOff(t *testing.T) {
+	offCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/off" {
+			offCalls++
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("turnHeatingOn returned an error: %v", err)
+	}
+	manager.cancelHeatingOff()
+	if manager.cancelHeating != nil {
+		t.Error("Expected pending heating off call to be cleared")
+	}
+	if offCalls != 0 {
+		t.Errorf("Expected no off calls after cancel, got %d", offCalls)
+	}
+}
+
+func TestNextWeeklyCheckDuration(t *testing.T) {
+	now := time.Date(2024, 3, 6, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		lastCheck *time.Time
+		weekday   string
+		expected  time.Duration
+	}{
+		{"no last check", nil, "", 0},
+		{"interval not elapsed", ptr(now.Add(-100 * time.Hour)), "", 68 * time.Hour},
+		{"interval elapsed", ptr(now.Add(-200 * time.Hour)), "", 0},
+		{"last check in the future", ptr(now.Add(time.Hour)), "", 169 * time.Hour},
+		{"weekday ahead", ptr(now.Add(-time.Hour)), "Sunday", 88*time.Hour + 30*time.Minute},
+		{"weekday occurrence missed", ptr(now.AddDate(0, 0, -8)), "Sunday", 0},
+	}
+	for _, tt := range tests {
+		manager := newTestManager(t)
+		manager.Clock = &fakeClock{now: now}
+		manager.Config.WeeklyCheckInterval = 168
+		manager.Config.WeeklyCheckWeekday = tt.weekday
+		manager.Config.WeeklyCheckHour = 3
+		if tt.lastCheck != nil {
+			if err := os.WriteFile(manager.LastCheckFile, []byte(tt.lastCheck.Format(time.RFC3339)), 0644); err != nil {
+				t.Fatalf("Failed to write last check file: %v", err)
+			}
+			manager.restoreState()
+		}
+
+		if d := manager.nextWeeklyCheckDuration(); d != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, d)
+		}
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestNextWeeklyCheckTimezone(t *testing.T) {
+	if _, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+	// Friday 12:00 UTC is Friday 21:00 in Tokyo.
+	clock := &fakeClock{now: time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.Timezone = "Asia/Tokyo"
+	manager.Config.WeeklyCheckWeekday = "Sunday"
+	manager.Config.WeeklyCheckHour = 3
+	manager.saveLastCheckTime(context.Background())
+
+	// Sunday 03:00 in Tokyo is Saturday 18:00 UTC.
+	if want := time.Date(2024, 3, 9, 18, 0, 0, 0, time.UTC); !manager.NextWeeklyCheck().Equal(want) {
+		t.Errorf("Expected the next check at %v, got %v", want, manager.NextWeeklyCheck().UTC())
+	}
+}
+
+func TestNextWeeklyCheckCron(t *testing.T) {
+	// 2024-03-06 is a Wednesday.
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 10, 30, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.Timezone = "UTC"
+	manager.Config.WeeklyCheckCron = "0 3 * * 0"
+	manager.saveLastCheckTime(context.Background())
+
+	if want := time.Date(2024, 3, 10, 3, 0, 0, 0, time.UTC); !manager.NextWeeklyCheck().Equal(want) {
+		t.Errorf("Expected the next check on Sunday at 3am, got %v", manager.NextWeeklyCheck())
+	}
+	if want := time.Date(2024, 3, 10, 3, 0, 0, 0, time.UTC).Sub(clock.now); manager.nextWeeklyCheckDuration() != want {
+		t.Errorf("Expected the next check in %v, got %v", want, manager.nextWeeklyCheckDuration())
+	}
+
+	// A missed run is still due.
+	clock.Advance(10 * 24 * time.Hour)
+	if d := manager.nextWeeklyCheckDuration(); d != 0 {
+		t.Errorf("Expected the missed check to be due now, got %v", d)
+	}
+}
+
+func TestNextWeekdayHour(t *testing.T) {
+	// 2024-03-06 is a Wednesday.
+	from := time.Date(2024, 3, 6, 10, 30, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		weekday  time.Weekday
+		hour     int
+		expected time.Time
+	}{
+		{"later this week", time.Sunday, 3, time.Date(2024, 3, 10, 3, 0, 0, 0, time.Local)},
+		{"later today", time.Wednesday, 14, time.Date(2024, 3, 6, 14, 0, 0, 0, time.Local)},
+		{"earlier today", time.Wednesday, 3, time.Date(2024, 3, 13, 3, 0, 0, 0, time.Local)},
+		{"earlier this week", time.Monday, 3, time.Date(2024, 3, 11, 3, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		if next := nextWeekdayHour(from, tt.weekday, tt.hour); !next.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, next)
+		}
+	}
+}
+
+func TestGetTemperatureRetryDeadline(t *testing.T) {
+	var calls atomic.Int32
+	manager := newTestManager(t)
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}
+	manager.Config.MaxRetries = 10
+	manager.Config.RetryBackoff = 40
+	manager.Config.MaxBackoffSeconds = 1
+
+	// With 40ms, 80ms and 160ms backoffs, only two retries fit into 200ms.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := manager.getTemperature(ctx, "http://shelly/temp"); err == nil || !strings.Contains(err.Error(), "status code 503") {
+		t.Errorf("Expected the last read error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Expected the retries to end before the deadline, took %v", elapsed)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.RetryDeadlineFraction = 0.25
+	if want := manager.checkInterval() / 4; manager.readDeadline() != want {
+		t.Errorf("Expected a read deadline of %v, got %v", want, manager.readDeadline())
+	}
+}
+
+func TestGetTemperatureServerError(t *testing.T) {
+	manager := newTestManager(t)
+	manager.HTTPClient = stubResponse(http.StatusInternalServerError, "")
+	manager.Config.RetryBackoff = 1
+
+	_, err := manager.getTemperature(context.Background(), "http://shelly/temp")
+	if err == nil || !strings.Contains(err.Error(), "status code 500") {
+		t.Errorf("Expected status code 500 error, got %v", err)
+	}
+}
+
+func TestGetTemperatureMalformedResponse(t *testing.T) {
+	portal := "<html><head><title>Captive portal</title></head><body>" + strings.Repeat("Please log in. ", 20) + "</body></html>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(portal))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	_, err := manager.getTemperature(context.Background(), ts.URL)
+	if err == nil {
+		t.Fatal("Expected an error for an HTML response")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"text/html"`) || !strings.Contains(msg, "<title>Captive portal</title>") || strings.Contains(msg, "</html>") {
+		t.Errorf("Expected the content type and a truncated body in the error, got %v", err)
+	}
+}
+
+func TestGetTemperatureResponseTooLarge(t *testing.T) {
+	manager := newTestManager(t)
+	manager.HTTPClient = stubResponse(http.StatusOK, strings.Repeat("1", 2048))
+	manager.Config.MaxResponseBytes = 1024
+	manager.Config.RetryBackoff = 1
+
+	_, err := manager.getTemperature(context.Background(), "http://shelly/temp")
+	if err == nil || !strings.Contains(err.Error(), "larger than 1024 bytes") {
+		t.Errorf("Expected an error for an oversized response, got %v", err)
+	}
+}
+
+func TestTurnShellyOnErrors(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+
+	manager.HTTPClient = stubResponse(http.StatusInternalServerError, "")
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController("http://shelly/on", "http://shelly/off"), false); err == nil {
+		t.Error("Expected turnHeatingOn to fail on status code 500")
+	}
+
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}}
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController("http://shelly/on", "http://shelly/off"), false); err == nil {
+		t.Error("Expected turnHeatingOn to fail when the device is unreachable")
+	}
+}
+
+func TestRetryHeatingOn(t *testing.T) {
+	tests := map[string]struct {
+		controller *stubController
+		wantErr    bool
+		wantCalls  int32
+	}{
+		"recovers":        {&stubController{onErr: errors.New("plug offline"), onFailures: 2}, false, 3},
+		"gives up":        {&stubController{onErr: errors.New("plug offline")}, true, 4},
+		"bad request":     {&stubController{onErr: &StatusError{StatusCode: http.StatusBadRequest}}, true, 1},
+		"relay stays off": {&stubController{onErr: fmt.Errorf("failed to turn on Shelly: %w", errRelayUnconfirmed)}, true, 1},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			manager := newTestManager(t)
+			manager.Config.RetryBackoff = 1
+			err := manager.switchHeatingOn(context.Background(), tt.controller)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if calls := tt.controller.onCalls.Load(); calls != tt.wantCalls {
+				t.Errorf("Expected %d on calls, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestWeeklyCheckHeatingFailure(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+	manager.Config.MaxRetries = 1
+	manager.Config.HeatingDurationMinutes = 0
+	received := notificationRecorder(t, manager)
+	controller := &stubController{onErr: errors.New("plug offline")}
+
+	for range 2 {
+		if err := manager.weeklyCheck(context.Background(), controller, false); err == nil {
+			t.Fatal("Expected weeklyCheck to fail")
+		}
+	}
+	state, err := manager.loadState()
+	if err != nil {
+		t.Fatalf("loadState returned an error: %v", err)
+	}
+	if state.HeatingFailedAt.IsZero() {
+		t.Error("Expected the failed heating to be recorded in the state")
+	}
+	if status := manager.status(); status.HeatingFailedAt == nil {
+		t.Error("Expected the failed heating in the status")
+	}
+	var failures []Notification
+	for _, n := range received() {
+		if n.Event == eventHeatingFailed {
+			failures = append(failures, n)
+		}
+	}
+	if len(failures) != 1 || failures[0].Severity != severityCritical {
+		t.Errorf("Expected one critical heating_failed notification, got %v", failures)
+	}
+
+	controller.onErr = nil
+	if err := manager.weeklyCheck(context.Background(), controller, false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.heatingWG.Wait()
+	if state, _ := manager.loadState(); !state.HeatingFailedAt.IsZero() {
+		t.Error("Expected the failure to be cleared once the weekly heating ran")
+	}
+}
+
+func TestDryRunDoesNotSwitchShelly(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.DryRun = true
+
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Errorf("turnHeatingOn returned an error: %v", err)
+	}
+	manager.cancelHeatingOff()
+	if err := manager.switchHeatingOff(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off")); err != nil {
+		t.Errorf("switchHeatingOff returned an error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected no requests in dry-run mode, got %d", calls)
+	}
+}
+
+func TestCheckTemperatureHysteresis(t *testing.T) {
+	var body atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.TemperatureThreshold = 55
+	manager.Config.ThresholdHysteresis = 2
+
+	steps := []struct {
+		temperature string
+		exceeded    bool
+	}{
+		{"55", false},   // at the threshold
+		{"55.1", true},  // just above the threshold
+		{"54", true},    // inside the hysteresis band
+		{"53", true},    // at the lower band edge
+		{"52.9", false}, // below the band
+		{"54.5", false}, // rising inside the band
+	}
+	for _, step := range steps {
+		body.Store(`{"id":100,"tC":` + step.temperature + `}`)
+		manager.checkTemperature(context.Background(), []string{ts.URL})
+		if manager.TemperatureExceeded != step.exceeded {
+			t.Errorf("At %s°C expected TemperatureExceeded=%v, got %v", step.temperature, step.exceeded, manager.TemperatureExceeded)
+		}
+	}
+}
+
+func TestCheckTemperatureWithoutHysteresisKeepsFlag(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"id":100,"tC":40}`))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ThresholdHysteresis = 0
+	manager.TemperatureExceeded = true
+
+	manager.checkTemperature(context.Background(), []string{ts.URL})
+	if !manager.TemperatureExceeded {
+		t.Error("TemperatureExceeded should stay set until the weekly check without hysteresis")
+	}
+}
+
+func TestParseTemperatureUnit(t *testing.T) {
+	manager := newTestManager(t)
+	body := []byte(`{"id":100,"tC":60,"tF":140}`)
+
+	for unit, expected := range map[string]float64{"C": 60, "F": 140} {
+		manager.Config.TemperatureUnit = unit
+		temp, err := manager.parseTemperature(body)
+		if err != nil {
+			t.Fatalf("parseTemperature returned an error: %v", err)
+		}
+		if temp != expected {
+			t.Errorf("Unit %s: expected %v, got %v", unit, expected, temp)
+		}
+	}
+}
+
+func TestReadFailureNotifications(t *testing.T) {
+	var events []string
+	sensorDown := true
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = "http://notify.example.com"
+	manager.Config.ShellyURLs = []string{"http://sensor.example.com/temperature"}
+	manager.Config.MaxConsecutiveFailures = 2
+	manager.Config.MaxRetries = 1
+	manager.Config.RetryBackoff = 1
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "notify.example.com" {
+			var notification Notification
+			if err := json.NewDecoder(req.Body).Decode(&notification); err != nil {
+				t.Errorf("Failed to decode notification: %v", err)
+			}
+			events = append(events, notification.Event)
+			return &http.Response{StatusCode: http.StatusNoContent, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		if sensorDown {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"tC":40,"tF":104}`))}, nil
+	}}
+
+	for i := 0; i < 4; i++ {
+		manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	}
+	sensorDown = false
+	manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+
+	expected := []string{eventReadFailures, eventReadRecovered}
+	if len(events) != len(expected) || events[0] != expected[0] || events[1] != expected[1] {
+		t.Errorf("Expected notifications %v, got %v", expected, events)
+	}
+}
+
+func TestNextWeeklyCheck(t *testing.T) {
+	manager := newTestManager(t)
+	if next := manager.NextWeeklyCheck(); !next.IsZero() {
+		t.Errorf("Expected a pending check without a recorded check, got %v", next)
+	}
+	if formatNextWeeklyCheck(manager.NextWeeklyCheck()) != "pending" {
+		t.Error("Expected a pending check to be shown as pending")
+	}
+
+	lastCheck := time.Now().Add(-time.Hour)
+	manager.lastCheck = lastCheck
+	expected := lastCheck.Add(time.Duration(manager.Config.WeeklyCheckInterval) * time.Hour)
+	if next := manager.NextWeeklyCheck(); !next.Equal(expected) {
+		t.Errorf("Expected next check at %v, got %v", expected, next)
+	}
+	if d := manager.nextWeeklyCheckDuration(); d <= 0 || d > time.Duration(manager.Config.WeeklyCheckInterval)*time.Hour {
+		t.Errorf("Unexpected duration until next check: %v", d)
+	}
+}
+
+func TestGetTemperatureAbortsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	manager := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := manager.getTemperature(ctx, ts.URL); err == nil {
+		t.Fatal("Expected a cancelled read to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the read to abort promptly, took %v", elapsed)
+	}
+}
+
+func TestNextCheckDelayJitter(t *testing.T) {
+	manager := newTestManager(t)
+	if delay := manager.nextCheckDelay(); delay != manager.CheckInterval {
+		t.Errorf("Expected %v without jitter, got %v", manager.CheckInterval, delay)
+	}
+
+	manager.Config.CheckJitterSeconds = 30
+	jitter := 30 * time.Second
+	for i := 0; i < 100; i++ {
+		delay := manager.nextCheckDelay()
+		if delay < manager.CheckInterval-jitter || delay > manager.CheckInterval+jitter {
+			t.Fatalf("Delay %v outside of %v ± %v", delay, manager.CheckInterval, jitter)
+		}
+	}
+}
+
+func TestTurnShellyOnMinHeatingInterval(t *testing.T) {
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/on" {
+			onCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.MinHeatingIntervalHours = 24
+	manager.lastHeatingRun = time.Now().Add(-time.Hour)
+	defer manager.cancelHeatingOff()
+
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); !errors.Is(err, errHeatingTooSoon) {
+		t.Errorf("Expected errHeatingTooSoon, got %v", err)
+	}
+	if onCalls.Load() != 0 {
+		t.Errorf("Expected no on call within the minimum interval, got %d", onCalls.Load())
+	}
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Errorf("Expected a skipped weekly check to succeed, got %v", err)
+	}
+	if _, err := manager.readLastCheckTime(); err != nil {
+		t.Errorf("Expected the skipped check to be recorded: %v", err)
+	}
+
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), true); err != nil {
+		t.Errorf("Expected a forced run to succeed, got %v", err)
+	}
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected the forced run to turn on the heating, got %d on calls", onCalls.Load())
+	}
+}
+
+func TestWeeklyCheckWithFakeClock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 10, 30, 0, 0, time.Local)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.WeeklyCheckInterval = 168
+	manager.Config.MinHeatingIntervalHours = 24
+	defer manager.cancelHeatingOff()
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if lastCheck, err := manager.readLastCheckTime(); err != nil || !lastCheck.Equal(clock.now) {
+		t.Errorf("Expected last check at %v, got %v (%v)", clock.now, lastCheck, err)
+	}
+	if d := manager.nextWeeklyCheckDuration(); d != 168*time.Hour {
+		t.Errorf("Expected the next check in 168h, got %v", d)
+	}
+	// The heating cycle of the first run has ended long before the fake clock moves on.
+	manager.cancelHeatingOff()
+	manager.heatingWG.Wait()
+
+	clock.Advance(12 * time.Hour)
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); !errors.Is(err, errHeatingTooSoon) {
+		t.Errorf("Expected errHeatingTooSoon 12h after the last run, got %v", err)
+	}
+	clock.Advance(168 * time.Hour)
+	if d := manager.nextWeeklyCheckDuration(); d != 0 {
+		t.Errorf("Expected an overdue check to run immediately, got %v", d)
+	}
+	if err := manager.turnHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Errorf("Expected the heating to turn on after the minimum interval, got %v", err)
+	}
+}
+
+// blockingController is a HeatingController whose On blocks until release is closed.
+type blockingController struct {
+	stubController
+	release chan struct{}
+}
+
+func (c *blockingController) On(ctx context.Context) error {
+	c.onCalls.Add(1)
+	<-c.release
+	return nil
+}
+
+func TestTurnHeatingOnSingleFlight(t *testing.T) {
+	manager := newTestManager(t)
+	controller := &blockingController{release: make(chan struct{})}
+	defer manager.cancelHeatingOff()
+
+	first := make(chan error)
+	go func() {
+		first <- manager.turnHeatingOn(context.Background(), controller, true)
+	}()
+	for controller.onCalls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A second run while the first is still switching on is refused, even forced.
+	if err := manager.turnHeatingOn(context.Background(), controller, true); !errors.Is(err, errHeatingInProgress) {
+		t.Errorf("Expected errHeatingInProgress while switching on, got %v", err)
+	}
+	close(controller.release)
+	if err := <-first; err != nil {
+		t.Fatalf("turnHeatingOn returned an error: %v", err)
+	}
+	// And so is one while the heating cycle runs.
+	if err := manager.turnHeatingOn(context.Background(), controller, true); !errors.Is(err, errHeatingInProgress) {
+		t.Errorf("Expected errHeatingInProgress while the cycle runs, got %v", err)
+	}
+	if controller.onCalls.Load() != 1 {
+		t.Errorf("Expected the heating to be turned on once, got %d", controller.onCalls.Load())
+	}
+}
+
+func TestWeeklyCheckConcurrentRuns(t *testing.T) {
+	manager := newTestManager(t)
+	controller := &stubController{}
+	manager.HeatingController = controller
+	defer manager.cancelHeatingOff()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(force bool) {
+			defer wg.Done()
+			if err := manager.weeklyCheck(context.Background(), manager.heatingController("", ""), force); err != nil {
+				t.Errorf("weeklyCheck returned an error: %v", err)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+	if controller.onCalls.Load() != 1 {
+		t.Errorf("Expected concurrent manual and scheduled runs to turn on the heating once, got %d", controller.onCalls.Load())
+	}
+}
+
+func TestStartWeeklyCheckCancel(t *testing.T) {
+	manager := newTestManager(t)
+	controller := &stubController{}
+	manager.HeatingController = controller
+	manager.mu.Lock()
+	manager.lastCheck = manager.Clock.Now()
+	manager.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.StartWeeklyCheck(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected StartWeeklyCheck to return right after cancelling, not after the pending wait")
+	}
+	if controller.onCalls.Load() != 0 {
+		t.Errorf("Expected no weekly heating, got %d on calls", controller.onCalls.Load())
+	}
+}
+
+func TestStartWeeklyCheckConfigReload(t *testing.T) {
+	manager := newTestManager(t)
+	controller := &stubController{}
+	manager.HeatingController = controller
+	manager.mu.Lock()
+	manager.lastCheck = manager.Clock.Now().Add(-2 * time.Hour)
+	manager.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.StartWeeklyCheck(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+		manager.heatingWG.Wait()
+	}()
+
+	config := manager.currentConfig()
+	config.WeeklyCheckInterval = 1
+	manager.applyConfig(config)
+
+	deadline := time.Now().Add(time.Second)
+	for controller.onCalls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if controller.onCalls.Load() != 1 {
+		t.Errorf("Expected a shortened weeklyCheckInterval to run the overdue check right away, got %d on calls", controller.onCalls.Load())
+	}
+}
+
+func TestHeatingOffAtPersisted(t *testing.T) {
+	manager := newTestManager(t)
+	controller := &stubController{}
+	manager.HeatingController = controller
+	manager.Config.HeatingDurationMinutes = 30
+
+	before := manager.Clock.Now()
+	if err := manager.turnHeatingOn(context.Background(), controller, false); err != nil {
+		t.Fatalf("turnHeatingOn returned an error: %v", err)
+	}
+	// Shutting down abandons the cycle, but keeps its off time.
+	manager.cancelHeatingOff()
+	manager.heatingWG.Wait()
+	state, err := manager.loadState()
+	if err != nil {
+		t.Fatalf("loadState returned an error: %v", err)
+	}
+	if want := before.Add(30 * time.Minute); state.HeatingOffAt.Before(want) || state.HeatingOffAt.After(want.Add(time.Minute)) {
+		t.Errorf("Expected the off time %v to be persisted, got %v", want, state.HeatingOffAt)
+	}
+
+	if err := manager.switchHeatingOff(context.Background(), controller); err != nil {
+		t.Fatalf("switchHeatingOff returned an error: %v", err)
+	}
+	if state, _ := manager.loadState(); !state.HeatingOffAt.IsZero() {
+		t.Errorf("Expected the off time to be cleared once the heating is off, got %v", state.HeatingOffAt)
+	}
+}
+
+func TestResumeHeatingOff(t *testing.T) {
+	tests := []struct {
+		name        string
+		offIn       time.Duration
+		wantRunning bool
+		wantCalls   int32
+	}{
+		{"off time passed", -time.Hour, false, 1},
+		{"off time pending", time.Hour, true, 0},
+		{"no cycle", 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+			manager := newTestManager(t)
+			manager.Clock = clock
+			offAt := time.Time{}
+			if tt.offIn != 0 {
+				offAt = clock.Now().Add(tt.offIn)
+				manager.heatingOffAt = offAt
+				if err := manager.saveState(); err != nil {
+					t.Fatalf("saveState returned an error: %v", err)
+				}
+			}
+
+			restarted := newTestManager(t)
+			restarted.Clock = clock
+			restarted.StateFile = manager.StateFile
+			restarted.restoreState()
+			controller := &stubController{}
+			restarted.HeatingController = controller
+			restarted.resumeHeatingOff(context.Background())
+
+			restarted.mu.Lock()
+			running := restarted.cancelHeating != nil
+			restarted.mu.Unlock()
+			if running != tt.wantRunning {
+				t.Errorf("Expected the cycle to be supervised: %v, got %v", tt.wantRunning, running)
+			}
+			restarted.cancelHeatingOff()
+			restarted.heatingWG.Wait()
+			if calls := controller.offCalls.Load(); calls != tt.wantCalls {
+				t.Errorf("Expected %d off calls, got %d", tt.wantCalls, calls)
+			}
+			// A pending off time stays persisted until the supervision turns the heating off.
+			if !tt.wantRunning {
+				offAt = time.Time{}
+			}
+			if state, _ := restarted.loadState(); !state.HeatingOffAt.Equal(offAt) {
+				t.Errorf("Expected the persisted off time %v after resuming, got %v", offAt, state.HeatingOffAt)
+			}
+		})
+	}
+}
+
+func TestRetryHeatingOff(t *testing.T) {
+	oldDelay := heatingOffRetryDelay
+	heatingOffRetryDelay = time.Millisecond
+	defer func() { heatingOffRetryDelay = oldDelay }()
+
+	var notifications []Notification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		notifications = append(notifications, n)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = ts.URL
+	// The relay is unreachable for the resume and the first retry, each trying twice.
+	controller := &stubController{offErr: ErrDeviceUnreachable, offFailures: 4}
+	manager.HeatingController = controller
+	manager.heatingOffAt = manager.Clock.Now().Add(-time.Hour)
+
+	manager.resumeHeatingOff(context.Background())
+	if manager.heatingOffAt.IsZero() {
+		t.Fatal("Expected the off time to be kept after a failed off call")
+	}
+	manager.retryHeatingOff(context.Background())
+	if manager.heatingOffAt.IsZero() {
+		t.Fatal("Expected the off time to be kept while the relay is unreachable")
+	}
+	manager.retryHeatingOff(context.Background())
+	if calls := controller.offCalls.Load(); calls != 5 {
+		t.Errorf("Expected 5 off calls, got %d", calls)
+	}
+	if state, _ := manager.loadState(); !state.HeatingOffAt.IsZero() {
+		t.Errorf("Expected the off time to be cleared once the heating is off, got %v", state.HeatingOffAt)
+	}
+	if len(notifications) != 1 || notifications[0].Event != eventHeatingOffFailed || notifications[0].Reason != reasonDeviceUnreachable {
+		t.Errorf("Expected a single heating_off_failed notification, got %+v", notifications)
+	}
+
+	manager.retryHeatingOff(context.Background())
+	if calls := controller.offCalls.Load(); calls != 5 {
+		t.Errorf("Expected no further off calls once the heating is off, got %d", calls)
+	}
+}
+
+func TestCheckTemperatureFallback(t *testing.T) {
+	fallbackUp := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/fallback" && fallbackUp:
+			_, _ = w.Write([]byte(`{"id":101,"tC":47.5}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyTempFallbackURL = ts.URL + "/fallback"
+	manager.Config.MaxRetries = 1
+	manager.Config.RetryBackoff = 1
+
+	manager.checkTemperature(context.Background(), []string{ts.URL + "/primary"})
+	if temp, _ := manager.CurrentTemperature(); temp != 47.5 {
+		t.Errorf("Expected the fallback reading 47.5, got %v", temp)
+	}
+
+	fallbackUp = false
+	_, _, err := manager.readTemperature(context.Background(), []string{ts.URL + "/primary"})
+	if err == nil || !strings.Contains(err.Error(), "fallback sensor") {
+		t.Errorf("Expected a combined error naming the fallback sensor, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// historyHeader is the header row written to a newly created history file.
+var historyHeader = []string{"timestamp", "temperature_celsius", "threshold_exceeded"}
+
+// appendHistory appends a temperature reading in Celsius to the configured CSV history file.
+// It does nothing if no history file is configured.
+func (hm *HeatingManager) appendHistory(readTime time.Time, temperature float64, exceeded bool) error {
+	config := hm.currentConfig()
+	if config.HistoryFile == "" {
+		return nil
+	}
+
+	hm.historyMu.Lock()
+	defer hm.historyMu.Unlock()
+
+	file, err := os.OpenFile(config.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat history file: %v", err)
+	}
+
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err := writer.Write(historyHeader); err != nil {
+			return fmt.Errorf("failed to write history header: %v", err)
+		}
+	}
+	record := []string{
+		readTime.Format(time.RFC3339),
+		strconv.FormatFloat(temperature, 'f', -1, 64),
+		strconv.FormatBool(exceeded),
+	}
+	if err := writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write history record: %v", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush history file: %v", err)
+	}
+
+	return file.Sync()
+}
+
+// Reading is a temperature reading in Celsius.
+type Reading struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+}
+
+// recordReading adds a reading in Celsius to the in-memory history.
+// A changed history size keeps the newest readings that still fit.
+func (hm *HeatingManager) recordReading(readTime time.Time, temperature float64) {
+	size := hm.currentConfig().HistorySize
+	if size <= 0 {
+		return
+	}
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if hm.recentReadings == nil || hm.recentReadings.size() != size {
+		history := newRing[Reading](size)
+		if hm.recentReadings != nil {
+			for _, reading := range hm.recentReadings.list() {
+				history.add(reading)
+			}
+		}
+		hm.recentReadings = history
+	}
+	hm.recentReadings.add(Reading{Time: readTime, Temperature: temperature})
+}
+
+// RecentReadings returns up to limit of the most recent readings, oldest first; a limit of 0 returns all.
+func (hm *HeatingManager) RecentReadings(limit int) []Reading {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if hm.recentReadings == nil {
+		return []Reading{}
+	}
+	list := hm.recentReadings.list()
+	if limit > 0 && limit < len(list) {
+		list = list[len(list)-limit:]
+	}
+	return list
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAppendHistory(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HistoryFile = filepath.Join(t.TempDir(), "history.csv")
+
+	readTime := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := manager.appendHistory(readTime, 54.5, false); err != nil {
+		t.Fatalf("appendHistory returned an error: %v", err)
+	}
+	if err := manager.appendHistory(readTime.Add(5*time.Minute), 56, true); err != nil {
+		t.Fatalf("appendHistory returned an error: %v", err)
+	}
+
+	data, err := os.ReadFile(manager.Config.HistoryFile)
+	if err != nil {
+		t.Fatalf("Failed to read history file: %v", err)
+	}
+	expected := "timestamp,temperature_celsius,threshold_exceeded\n" +
+		"2024-03-10T12:00:00Z,54.5,false\n" +
+		"2024-03-10T12:05:00Z,56,true\n"
+	if string(data) != expected {
+		t.Errorf("Expected history:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestRecentReadings(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HistorySize = 3
+
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		manager.recordReading(start.Add(time.Duration(i)*time.Minute), 50+float64(i))
+	}
+	readings := manager.RecentReadings(0)
+	if len(readings) != 3 || readings[0].Temperature != 52 || readings[2].Temperature != 54 {
+		t.Errorf("Expected the 3 newest readings, oldest first, got %v", readings)
+	}
+	if readings := manager.RecentReadings(1); len(readings) != 1 || readings[0].Temperature != 54 {
+		t.Errorf("Expected only the newest reading, got %v", readings)
+	}
+
+	manager.Config.HistorySize = 2
+	manager.recordReading(start.Add(5*time.Minute), 55)
+	if readings := manager.RecentReadings(0); len(readings) != 2 || readings[0].Temperature != 54 || readings[1].Temperature != 55 {
+		t.Errorf("Expected the newest readings to survive a resize, got %v", readings)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// hookWaitDelay bounds how long a killed heating command's output is still read.
+	hookWaitDelay = time.Second
+	// hookEnvPrefix starts the names of the event variables, and of the config overrides kept from the commands.
+	hookEnvPrefix = "PV_"
+)
+
+// runHook runs a heating command in the background with the event details in PV_* environment variables.
+// A switch error is passed in PV_ERROR. The command inherits the environment without the PV_* variables,
+// so the config overrides, including the passwords and tokens, stay with the manager. The command runs through sh -c and is killed after HookTimeoutSeconds;
+// its output is logged and a failure only logged, never affecting the heating.
+func (hm *HeatingManager) runHook(ctx context.Context, command, event string, switchErr error) {
+	if command == "" {
+		return
+	}
+	config := hm.currentConfig()
+	temperature, _ := hm.CurrentTemperature()
+	env := append(hookEnviron(),
+		"PV_EVENT="+event,
+		"PV_TIME="+hm.Clock.Now().Format(time.RFC3339),
+		"PV_TEMPERATURE="+config.formatTemperature(temperature),
+		"PV_TEMPERATURE_UNIT="+config.TemperatureUnit,
+	)
+	if hm.Zone != "" {
+		env = append(env, "PV_ZONE="+hm.Zone)
+	}
+	if switchErr != nil {
+		env = append(env, "PV_ERROR="+switchErr.Error())
+	}
+	timeout := time.Duration(config.HookTimeoutSeconds) * time.Second
+
+	hm.hookWG.Add(1)
+	go func() {
+		defer hm.hookWG.Done()
+		ctx, cancel := context.WithTimeout(detach(ctx), timeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Env = env
+		// A child of the shell may outlive it and keep the output open; stop waiting for it.
+		cmd.WaitDelay = hookWaitDelay
+		output, err := cmd.CombinedOutput()
+		out := strings.TrimSpace(string(output))
+		if ctx.Err() != nil {
+			slog.WarnContext(ctx, "Heating command timed out", "event", event, "command", command, "timeout", timeout, "output", out)
+			return
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Heating command failed", "event", event, "command", command, "err", err, "output", out)
+			return
+		}
+		slog.InfoContext(ctx, "Heating command finished", "event", event, "command", command, "output", out)
+	}()
+}
+
+// hookEnviron returns the environment of the manager without the variables starting with hookEnvPrefix.
+func hookEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, hookEnvPrefix) {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
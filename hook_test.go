@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHook(t *testing.T) {
+	manager := newTestManager(t)
+	out := filepath.Join(t.TempDir(), "hook.out")
+
+	manager.runHook(context.Background(), `echo "$PV_EVENT $PV_ERROR" > `+out, eventHeatingOff, errors.New("relay offline"))
+	manager.hookWG.Wait()
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the command to run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "heating_off relay offline" {
+		t.Errorf("Expected the event details in the environment, got %q", got)
+	}
+}
+
+func TestRunHookEnvironment(t *testing.T) {
+	t.Setenv("PV_API_TOKEN", "secret")
+	t.Setenv("PV_ERROR", "inherited")
+	t.Setenv("PV_CUSTOM", "custom")
+	manager := newTestManager(t)
+	out := filepath.Join(t.TempDir(), "hook.out")
+
+	manager.runHook(context.Background(), `echo "$PV_API_TOKEN|$PV_ERROR|$PV_CUSTOM|$PATH" > `+out, eventHeatingOn, nil)
+	manager.hookWG.Wait()
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the command to run: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(data)), "|||"+os.Getenv("PATH"); got != want {
+		t.Errorf("Expected the PV_* variables of the manager to be removed and the rest kept, got %q, want %q", got, want)
+	}
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HookTimeoutSeconds = 1
+
+	start := time.Now()
+	manager.runHook(context.Background(), "sleep 10", eventHeatingOn, nil)
+	manager.hookWG.Wait()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed after the timeout, took %v", elapsed)
+	}
+}
+
+func TestSwitchHeatingRunsHooks(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+	dir := t.TempDir()
+	manager.Config.OnHeatingCommand = `echo "$PV_EVENT" >> ` + filepath.Join(dir, "ok")
+	manager.Config.OnHeatingFailCommand = `echo "$PV_EVENT" >> ` + filepath.Join(dir, "fail")
+
+	controller := &stubController{}
+	if err := manager.switchHeatingOn(context.Background(), controller); err != nil {
+		t.Fatalf("switchHeatingOn returned an error: %v", err)
+	}
+	manager.hookWG.Wait()
+	controller.onErr = errors.New("plug offline")
+	if err := manager.switchHeatingOn(context.Background(), controller); err == nil {
+		t.Fatal("Expected switchHeatingOn to fail")
+	}
+	manager.hookWG.Wait()
+
+	if data, _ := os.ReadFile(filepath.Join(dir, "ok")); strings.TrimSpace(string(data)) != eventHeatingOn {
+		t.Errorf("Expected onHeatingCommand to run once for heating_on, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "fail")); strings.TrimSpace(string(data)) != eventHeatingOn {
+		t.Errorf("Expected onHeatingFailCommand to run once for heating_on, got %q", data)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxMeasurement is the InfluxDB measurement temperature readings are written to.
+const influxMeasurement = "tank_temp"
+
+// influxTagEscaper escapes the characters with a special meaning in line protocol tag values.
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxLine formats a temperature reading in Celsius as an InfluxDB line protocol point with second precision.
+func influxLine(readTime time.Time, celsius float64, source string) string {
+	return fmt.Sprintf("%s,source=%s celsius=%s %d", influxMeasurement, influxTagEscaper.Replace(source), strconv.FormatFloat(celsius, 'f', -1, 64), readTime.Unix())
+}
+
+// writeInflux writes a temperature reading in Celsius to InfluxDB via the v2 write API.
+// It does nothing if no InfluxDB URL is configured.
+func (hm *HeatingManager) writeInflux(ctx context.Context, readTime time.Time, celsius float64, source string) error {
+	config := hm.currentConfig()
+	if config.InfluxURL == "" {
+		return nil
+	}
+
+	query := url.Values{}
+	query.Set("org", config.InfluxOrg)
+	query.Set("bucket", config.InfluxBucket)
+	query.Set("precision", "s")
+	endpoint := strings.TrimSuffix(config.InfluxURL, "/") + "/api/v2/write?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(influxLine(readTime, celsius, source)))
+	if err != nil {
+		return fmt.Errorf("failed to create InfluxDB request: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if config.InfluxToken != "" {
+		req.Header.Set("Authorization", "Token "+config.InfluxToken)
+	}
+	resp, err := hm.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write to InfluxDB: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to write to InfluxDB: status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInfluxLine(t *testing.T) {
+	readTime := time.Unix(1700000000, 0)
+	if got, want := influxLine(readTime, 55.5, "primary"), "tank_temp,source=primary celsius=55.5 1700000000"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := influxLine(readTime, 60, "top sensor,1"), `tank_temp,source=top\ sensor\,1 celsius=60 1700000000`; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteInflux(t *testing.T) {
+	var gotPath, gotQuery, gotAuth, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotQuery = r.URL.RawQuery
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.InfluxURL = ts.URL + "/"
+	manager.Config.InfluxToken = "secret"
+	manager.Config.InfluxOrg = "home"
+	manager.Config.InfluxBucket = "heating"
+
+	if err := manager.writeInflux(context.Background(), time.Unix(1700000000, 0), 52.5, sourceFallback); err != nil {
+		t.Fatalf("writeInflux returned an error: %v", err)
+	}
+	if gotPath != "/api/v2/write" {
+		t.Errorf("Expected the write API path, got %q", gotPath)
+	}
+	if gotQuery != "bucket=heating&org=home&precision=s" {
+		t.Errorf("Unexpected query %q", gotQuery)
+	}
+	if gotAuth != "Token secret" {
+		t.Errorf("Expected the token in the Authorization header, got %q", gotAuth)
+	}
+	if gotBody != "tank_temp,source=fallback celsius=52.5 1700000000" {
+		t.Errorf("Unexpected line protocol body %q", gotBody)
+	}
+}
+
+func TestCheckTemperatureInfluxFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/write" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("50"))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{ts.URL + "/temp"}
+	manager.Config.InfluxURL = ts.URL
+	manager.Config.InfluxBucket = "heating"
+
+	if err := manager.checkTemperature(context.Background(), manager.Config.ShellyURLs); err != nil {
+		t.Fatalf("Expected a failed InfluxDB write not to fail the check, got %v", err)
+	}
+	if manager.LastTemperature != 50 {
+		t.Errorf("Expected last temperature 50, got %v", manager.LastTemperature)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// extractJSONNumber returns the number at a dotted path like "result.tC" or "sensors.0.value" in a JSON document.
+// Path segments select object keys, or array elements by index.
+func extractJSONNumber(body []byte, path string) (float64, error) {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return 0, fmt.Errorf("path %s: key %q not found", path, key)
+			}
+			value = child
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return 0, fmt.Errorf("path %s: invalid index %q for an array of %d elements", path, key, len(node))
+			}
+			value = node[i]
+		default:
+			return 0, fmt.Errorf("path %s: cannot select %q from %T", path, key, value)
+		}
+	}
+
+	number, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("path %s: expected a number, got %T", path, value)
+	}
+	return number, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestExtractJSONNumber(t *testing.T) {
+	tests := []struct {
+		body    string
+		path    string
+		want    float64
+		wantErr bool
+	}{
+		{`{"result":{"id":100,"tC":48.2}}`, "result.tC", 48.2, false},
+		{`{"temperature":{"value":21.5,"unit":"C"}}`, "temperature.value", 21.5, false},
+		{`{"sensors":[{"value":30},{"value":31.5}]}`, "sensors.1.value", 31.5, false},
+		{`{"temperature":{"value":21.5}}`, "temperature.celsius", 0, true},
+		{`{"temperature":{"unit":"C"}}`, "temperature.unit", 0, true},
+		{`{"sensors":[{"value":30}]}`, "sensors.2.value", 0, true},
+		{`{"temperature":21.5}`, "temperature.value", 0, true},
+		{`<html></html>`, "temperature", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := extractJSONNumber([]byte(tt.body), tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractJSONNumber(%s, %s): expected error %v, got %v", tt.body, tt.path, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("extractJSONNumber(%s, %s) = %v, want %v", tt.body, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseTemperatureJSONPath(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.TempJSONPath = "data.temperature"
+	temperature, err := manager.parseTemperature([]byte(`{"data":{"temperature":52.25,"humidity":40}}`))
+	if err != nil || temperature != 52.25 {
+		t.Errorf("Expected 52.25, got %v (%v)", temperature, err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// newLogger creates a JSON logger writing to w that drops records below the given level
+// and writes all timestamps in the given location. Records logged with a context carrying a correlation ID
+// include it. The level must have passed config validation.
+func newLogger(w io.Writer, level string, location *time.Location) *slog.Logger {
+	var minLevel slog.Level
+	_ = minLevel.UnmarshalText([]byte(level))
+	return slog.New(correlationHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: minLevel,
+		ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+			if attr.Value.Kind() == slog.KindTime {
+				attr.Value = slog.TimeValue(attr.Value.Time().In(location))
+			}
+			return attr
+		},
+	})})
+}
+
+// rotatingWriter appends to a log file and rotates it once it would grow beyond maxSize bytes.
+// Rotated files are named path.1 (newest) to path.<maxBackups> (oldest); older ones are deleted.
+type rotatingWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	limit      int64     // Size at which the file is rotated next, maxSize unless a rotation failed.
+	errOutput  io.Writer // Receives rotation errors, which cannot go into the log itself.
+}
+
+// newRotatingWriter opens the log file at path for appending. maxBackups must be positive.
+func newRotatingWriter(path string, maxSizeMB, maxBackups int) (*rotatingWriter, error) {
+	maxSize := int64(maxSizeMB) * 1024 * 1024
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxBackups: maxBackups, limit: maxSize, errOutput: os.Stderr}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open opens the log file and records its current size.
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating it first if p does not fit.
+// A single write larger than the maximum size still goes into one file.
+// If rotating fails, the error is reported on errOutput and the file keeps growing
+// until it exceeds the maximum size once more.
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file != nil && w.size > 0 && w.size+int64(len(p)) > w.limit {
+		if err := w.rotate(); err != nil {
+			fmt.Fprintf(w.errOutput, "Failed to rotate log file %s: %v\n", w.path, err)
+			w.limit = w.size + w.maxSize
+		} else {
+			w.limit = w.maxSize
+		}
+	}
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups by one, moves the current file to path.1 and starts a new file.
+// The log file is reopened even if a step fails, appending to the old file if it could not be moved;
+// it stays closed only if reopening fails, to be retried by the next write.
+func (w *rotatingWriter) rotate() error {
+	var errs []error
+	if err := w.file.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close log file: %v", err))
+	}
+	w.file = nil
+	if err := os.Remove(w.backupName(w.maxBackups)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("failed to remove oldest log backup: %v", err))
+	}
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(w.backupName(i), w.backupName(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to shift log backup: %v", err))
+		}
+	}
+	if err := os.Rename(w.path, w.backupName(1)); err != nil {
+		errs = append(errs, fmt.Errorf("failed to move log file: %v", err))
+	}
+	if err := w.open(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// backupName returns the name of the i-th rotated log file.
+func (w *rotatingWriter) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+// Close closes the log file.
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
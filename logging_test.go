@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "warn", time.UTC)
+
+	logger.Info("Temperature is OK", "temperature", 50.0)
+	if buf.Len() != 0 {
+		t.Errorf("Expected info record to be dropped at warn level, got %s", buf.String())
+	}
+
+	logger.Warn("Failed to get temperature", "temperature", 50.0)
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON log record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "Failed to get temperature" || record["level"] != "WARN" || record["temperature"] != 50.0 {
+		t.Errorf("Unexpected log record: %v", record)
+	}
+}
+
+func TestNewLoggerLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+	var buf bytes.Buffer
+	logger := newLogger(&buf, "info", tokyo)
+
+	lastCheck := time.Date(2024, 3, 3, 3, 0, 0, 0, time.UTC)
+	logger.Info("Weekly check done", "lastCheck", lastCheck)
+	var record map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON log record, got %q: %v", buf.String(), err)
+	}
+	if !strings.HasSuffix(record["time"], "+09:00") || record["lastCheck"] != "2024-03-03T12:00:00+09:00" {
+		t.Errorf("Expected timestamps in Asia/Tokyo, got %v", record)
+	}
+}
+
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heating_manager.log")
+	w, err := newRotatingWriter(path, 1, 2)
+	if err != nil {
+		t.Fatalf("newRotatingWriter returned an error: %v", err)
+	}
+	defer w.Close()
+
+	// Each chunk fills most of the 1 MB limit, so every write after the first rotates.
+	chunk := bytes.Repeat([]byte("x"), 700*1024)
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("Write returned an error: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("Expected %s to exist: %v", name, err)
+		} else if info.Size() != int64(len(chunk)) {
+			t.Errorf("Expected %s to hold one chunk, got %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only 2 backups to be kept, got %v", err)
+	}
+}
+
+func TestRotatingWriterRotationFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heating_manager.log")
+	w, err := newRotatingWriter(path, 1, 1)
+	if err != nil {
+		t.Fatalf("newRotatingWriter returned an error: %v", err)
+	}
+	defer w.Close()
+	var errOutput bytes.Buffer
+	w.errOutput = &errOutput
+
+	// A non-empty directory in place of the backup can neither be removed nor replaced.
+	if err := os.MkdirAll(filepath.Join(path+".1", "blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The third chunk exceeds the 1 MB limit, the fourth still fits before the retry.
+	chunk := bytes.Repeat([]byte("x"), 400*1024)
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(chunk); err != nil {
+			t.Fatalf("Write returned an error: %v", err)
+		}
+	}
+
+	if !strings.Contains(errOutput.String(), "failed to remove oldest log backup") || !strings.Contains(errOutput.String(), "failed to move log file") {
+		t.Errorf("Expected the failed rotation steps to be reported, got %q", errOutput.String())
+	}
+	if strings.Count(errOutput.String(), "Failed to rotate") != 1 {
+		t.Errorf("Expected the rotation to be retried only after another maximum size, got %q", errOutput.String())
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != 4*int64(len(chunk)) {
+		t.Errorf("Expected logging to continue in the old file, got %v (%v)", info, err)
+	}
+}
@@ -1,26 +1,186 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 )
 
 // HeatingManager is the main entry point of the program.
 // It initializes a new HeatingManager instance and
-// starts two goroutines for temperature monitoring and weekly check.
-// The program then enters an infinite loop, waiting for events.
+// starts two goroutines for temperature monitoring and weekly check per zone.
+// With -once it runs a single check instead and exits, with -simulate it replays a CSV file and exits.
+// SIGHUP reloads the config file. The program waits until it receives SIGINT or SIGTERM
+// and shuts down once all goroutines have finished.
 func main() {
+	configPath := flag.String("config", defaultConfigPath(), "path to the configuration file (default from $"+configPathEnv+")")
+	dryRun := flag.Bool("dry-run", false, "log heating actions instead of switching the Shelly")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	check := flag.Bool("check", false, "validate the config, read each sensor once and exit without switching the heating")
+	once := flag.Bool("once", false, "run a single check selected by -check-type and exit")
+	checkType := flag.String("check-type", onceTemperature, "check run by -once: "+onceTemperature+" or "+onceWeekly)
+	simulate := flag.String("simulate", "", "replay the timestamped temperatures of a CSV file in dry-run mode and exit")
+	simulateSpeed := flag.Float64("simulate-speed", 0, "speed-up factor of -simulate, 0 replays as fast as possible")
+	simulateZone := flag.String("simulate-zone", "", "zone whose settings -simulate replays the file with, required with zones")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+	if *check {
+		if !runConfigCheck(context.Background(), os.Stdout, *configPath) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize a new HeatingManager instance
-	manager, err := NewHeatingManager()
+	manager, err := NewHeatingManager(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to initialize heating manager: %v", err)
+		slog.Error("Failed to initialize heating manager", "err", err)
+		os.Exit(1)
+	}
+	config := manager.currentConfig()
+	if *dryRun {
+		config.DryRun = true
+		manager.applyConfig(config)
+		manager.applyZoneConfigs(config)
+	}
+
+	// Log as JSON at the configured level from here on, to the log file if configured
+	if config.LogFile != "" {
+		logFile, err := newRotatingWriter(config.LogFile, config.LogMaxSizeMB, config.LogMaxBackups)
+		if err != nil {
+			slog.Error("Failed to open log file", "file", config.LogFile, "err", err)
+			os.Exit(1)
+		}
+		defer logFile.Close()
+		manager.LogOutput = logFile
+	}
+	slog.SetDefault(newLogger(manager.LogOutput, config.LogLevel, config.location()))
+	slog.Info("Starting heating manager", "version", version, "commit", commit, "buildDate", buildDate)
+
+	// Cancel the context on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Run a single check for an external scheduler instead of the loops
+	if *once {
+		var errs []error
+		for _, zone := range manager.zoneManagers() {
+			errs = append(errs, zone.runOnce(ctx, *checkType))
+			// Heating commands are bounded by their timeout.
+			zone.hookWG.Wait()
+		}
+		if err := errors.Join(errs...); err != nil {
+			slog.Error("Check failed", "checkType", *checkType, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	// Replay recorded temperatures without touching the hardware
+	if *simulate != "" {
+		zone := manager
+		if len(manager.zones) > 0 {
+			zone = manager.zoneManager(*simulateZone)
+		}
+		if zone == nil {
+			slog.Error("-simulate-zone must name a configured zone", "zone", *simulateZone)
+			os.Exit(1)
+		}
+		if err := zone.runSimulation(ctx, *simulate, *simulateSpeed); err != nil {
+			slog.Error("Simulation failed", "file", *simulate, "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	manager.notifyService(context.Background(), eventServiceStarted, reasonStartup)
+
+	var wg sync.WaitGroup
+
+	for _, zone := range manager.zoneManagers() {
+		// Start temperature monitoring of each zone in a separate goroutine
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zone.StartTemperatureMonitoring(ctx)
+		}()
+
+		// Start weekly check of each zone in a separate goroutine
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			zone.StartWeeklyCheck(ctx)
+		}()
 	}
 
-	// Start temperature monitoring in a separate goroutine
-	go manager.StartTemperatureMonitoring()
+	// Start PV surplus control in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartPVSurplusControl(ctx)
+	}()
+
+	// Serve Prometheus metrics in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartMetricsServer(ctx)
+	}()
+
+	// Serve the health check endpoint in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartHealthServer(ctx)
+	}()
+
+	// Publish state to MQTT in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartMQTT(ctx)
+	}()
 
-	// Start weekly check in a separate goroutine
-	go manager.StartWeeklyCheck()
+	// Reload the config file on SIGHUP in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartConfigReload(ctx, *configPath, *dryRun)
+	}()
 
-	// Wait for events in an infinite loop
-	select {}
+	// Serve the REST API in a separate goroutine
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		manager.StartAPIServer(ctx)
+	}()
+
+	// Wait for a shutdown signal, then let running checks finish their state writes
+	<-ctx.Done()
+	slog.Info("shutting down gracefully")
+	wg.Wait()
+	for _, zone := range manager.zoneManagers() {
+		zone.hookWG.Wait()
+	}
+	manager.hookWG.Wait()
+	manager.notifyService(context.Background(), eventServiceStopped, reasonShutdown)
+}
+
+// configPathEnv is the environment variable consulted when the -config flag is not set.
+const configPathEnv = "PV_HEATING_CONFIG"
+
+// defaultConfigPath returns the config path from the environment, falling back to config.json.
+func defaultConfigPath() string {
+	if path := os.Getenv(configPathEnv); path != "" {
+		return path
+	}
+	return "config.json"
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus metrics registered with the default registry, labelled with the zone they belong to.
+var (
+	temperatureGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "heating_manager_temperature_celsius",
+		Help: "Last temperature read from the Shelly device in Celsius.",
+	}, []string{"zone"})
+	temperatureReadFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "heating_manager_temperature_read_failures_total",
+		Help: "Total number of failed temperature reads.",
+	}, []string{"zone"})
+	heatingActivations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "heating_manager_heating_activations_total",
+		Help: "Total number of times the heating was turned on.",
+	}, []string{"zone"})
+	thresholdExceededGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "heating_manager_temperature_exceeded",
+		Help: "Whether the temperature threshold has been exceeded since the last weekly check (1) or not (0).",
+	}, []string{"zone"})
+	coolingRateGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "heating_manager_cooling_rate_celsius_per_hour",
+		Help: "Temperature drop per hour since the temperature last dropped below the threshold.",
+	}, []string{"zone"})
+)
+
+// Scheduling metrics computed from the weekly check state of each zone at scrape time.
+var (
+	nextWeeklyCheckDesc = prometheus.NewDesc(
+		"heating_manager_seconds_until_next_weekly_check",
+		"Seconds until the next weekly check is due, 0 if it is due now.",
+		[]string{"zone"}, nil,
+	)
+	sinceLastCheckDesc = prometheus.NewDesc(
+		"heating_manager_seconds_since_last_check",
+		"Seconds since the last weekly check, not exported before the first check.",
+		[]string{"zone"}, nil,
+	)
+)
+
+// schedulingCollector exports the weekly check schedule of every zone, so a stuck scheduler can be alerted on.
+type schedulingCollector struct {
+	hm *HeatingManager
+}
+
+// Describe sends the descriptors of the scheduling metrics.
+func (c schedulingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nextWeeklyCheckDesc
+	ch <- sinceLastCheckDesc
+}
+
+// Collect sends the current scheduling metrics of every zone.
+func (c schedulingCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, zm := range c.hm.zoneManagers() {
+		zone := zm.zoneName()
+		ch <- prometheus.MustNewConstMetric(nextWeeklyCheckDesc, prometheus.GaugeValue, zm.nextWeeklyCheckDuration().Seconds(), zone)
+		if lastCheck, err := zm.readLastCheckTime(); err == nil {
+			ch <- prometheus.MustNewConstMetric(sinceLastCheckDesc, prometheus.GaugeValue, zm.Clock.Now().Sub(lastCheck).Seconds(), zone)
+		}
+	}
+}
+
+// metricsHandler serves the metrics of the default registry together with the scheduling metrics.
+func (hm *HeatingManager) metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(schedulingCollector{hm: hm})
+	return promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, promhttp.HandlerOpts{})
+}
+
+// StartMetricsServer serves Prometheus metrics on /metrics until the context is cancelled.
+// It does nothing if no metrics port is configured.
+func (hm *HeatingManager) StartMetricsServer(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.MetricsPort == 0 {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", hm.metricsHandler())
+	serveHTTP(ctx, fmt.Sprintf(":%d", config.MetricsPort), config.HTTPBasePath, hm.requireBasicAuth(mux))
+}
+
+// boolToFloat converts a bool to a 0/1 gauge value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
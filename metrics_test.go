@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrapeMetrics returns the metrics served by the manager's metrics handler.
+func scrapeMetrics(t *testing.T, manager *HeatingManager) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	manager.metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	return rec.Body.String()
+}
+
+func TestSchedulingMetrics(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.StartupGracePeriodMinutes = 0
+
+	body := scrapeMetrics(t, manager)
+	if !strings.Contains(body, `heating_manager_seconds_until_next_weekly_check{zone="default"} 0`) {
+		t.Errorf("Expected the first weekly check to be due, got %s", body)
+	}
+	if strings.Contains(body, "heating_manager_seconds_since_last_check") {
+		t.Error("Expected no time since the last check before the first check")
+	}
+	if !strings.Contains(body, "heating_manager_temperature_exceeded ") {
+		t.Error("Expected the default registry metrics to be served as well")
+	}
+
+	manager.lastCheck = clock.now
+	clock.Advance(2 * time.Hour)
+	body = scrapeMetrics(t, manager)
+	if !strings.Contains(body, `heating_manager_seconds_since_last_check{zone="default"} 7200`) {
+		t.Errorf("Expected 7200 seconds since the last check, got %s", body)
+	}
+	if !strings.Contains(body, `heating_manager_seconds_until_next_weekly_check{zone="default"} 597600`) {
+		t.Errorf("Expected 166 hours until the next weekly check, got %s", body)
+	}
+}
+
+func TestSchedulingMetricsZones(t *testing.T) {
+	manager := newTestZoneManager(t)
+	manager.zoneManager("tank2").lastCheck = time.Now()
+
+	body := scrapeMetrics(t, manager)
+	for _, want := range []string{
+		`heating_manager_seconds_until_next_weekly_check{zone="tank1"}`,
+		`heating_manager_seconds_until_next_weekly_check{zone="tank2"}`,
+		`heating_manager_seconds_since_last_check{zone="tank2"}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in %s", want, body)
+		}
+	}
+	if strings.Contains(body, `heating_manager_seconds_until_next_weekly_check{zone="default"}`) {
+		t.Error("Expected only the configured zones")
+	}
+}
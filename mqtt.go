@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttTimeout bounds publishing a single MQTT message and disconnecting.
+const mqttTimeout = 5 * time.Second
+
+// homeAssistantDiscoveryPrefix is the topic prefix Home Assistant watches for discovery messages.
+const homeAssistantDiscoveryPrefix = "homeassistant"
+
+// MQTT payloads of the heating switch.
+const (
+	mqttOn  = "ON"
+	mqttOff = "OFF"
+)
+
+// mqttPublisher publishes messages to an MQTT broker; tests can inject stubs.
+type mqttPublisher interface {
+	Publish(topic string, payload []byte, retained bool) error
+}
+
+// pahoPublisher publishes through a paho MQTT client.
+type pahoPublisher struct {
+	client paho.Client
+}
+
+// Publish publishes a message with QoS 1, failing fast while the broker is unreachable.
+func (p pahoPublisher) Publish(topic string, payload []byte, retained bool) error {
+	if !p.client.IsConnectionOpen() {
+		return errors.New("not connected to MQTT broker")
+	}
+	token := p.client.Publish(topic, 1, retained, payload)
+	if !token.WaitTimeout(mqttTimeout) {
+		return fmt.Errorf("timed out publishing to %s", topic)
+	}
+	return token.Error()
+}
+
+// haDevice identifies the heating manager as a Home Assistant device.
+type haDevice struct {
+	Identifiers []string `json:"identifiers"`
+	Name        string   `json:"name"`
+	SWVersion   string   `json:"sw_version,omitempty"`
+}
+
+// haDiscovery is a Home Assistant MQTT discovery config message.
+type haDiscovery struct {
+	Name              string   `json:"name"`
+	UniqueID          string   `json:"unique_id"`
+	StateTopic        string   `json:"state_topic"`
+	CommandTopic      string   `json:"command_topic,omitempty"`
+	AvailabilityTopic string   `json:"availability_topic"`
+	DeviceClass       string   `json:"device_class,omitempty"`
+	UnitOfMeasurement string   `json:"unit_of_measurement,omitempty"`
+	Device            haDevice `json:"device"`
+}
+
+// mqttTopics are the topics used below the configured prefix.
+type mqttTopics struct {
+	temperature  string
+	heatingState string
+	heatingSet   string
+	availability string
+}
+
+// newMQTTTopics returns the MQTT topics of a zone below the given prefix. The topics of a zone
+// are nested below its name, except for the availability shared by the whole process;
+// an empty zone uses the prefix itself.
+func newMQTTTopics(prefix, zone string) mqttTopics {
+	zonePrefix := prefix
+	if zone != "" {
+		zonePrefix += "/" + zone
+	}
+	return mqttTopics{
+		temperature:  zonePrefix + "/temperature",
+		heatingState: zonePrefix + "/heating",
+		heatingSet:   zonePrefix + "/heating/set",
+		availability: prefix + "/status",
+	}
+}
+
+// mqttTopics returns the MQTT topics of the zone heated by the manager.
+func (hm *HeatingManager) mqttTopics() mqttTopics {
+	return newMQTTTopics(hm.currentConfig().MQTTTopicPrefix, hm.Zone)
+}
+
+// StartMQTT connects to the MQTT broker, announces the temperature sensor and heating switch
+// of every zone via Home Assistant discovery and handles switch commands until the context is cancelled.
+// It does nothing if no broker is configured.
+func (hm *HeatingManager) StartMQTT(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.MQTTBroker == "" {
+		return
+	}
+	topics := hm.mqttTopics()
+	zones := hm.zoneManagers()
+	// Commands run outside paho's message router, which must not block or publish.
+	var commands sync.WaitGroup
+
+	opts := paho.NewClientOptions().
+		AddBroker(config.MQTTBroker).
+		SetClientID(config.MQTTTopicPrefix).
+		SetUsername(config.MQTTUsername).
+		SetPassword(config.MQTTPassword).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetWill(topics.availability, "offline", 1, true).
+		SetOnConnectHandler(func(client paho.Client) {
+			slog.Info("Connected to MQTT broker", "broker", config.MQTTBroker)
+			publisher := pahoPublisher{client: client}
+			for _, zm := range zones {
+				if err := zm.publishMQTTDiscovery(publisher); err != nil {
+					slog.Warn("Failed to publish MQTT discovery", "zone", zm.zoneName(), "err", err)
+				}
+				client.Subscribe(zm.mqttTopics().heatingSet, 1, func(_ paho.Client, msg paho.Message) {
+					commands.Add(1)
+					go func() {
+						defer commands.Done()
+						zm.handleMQTTCommand(ctx, string(msg.Payload()))
+					}()
+				})
+			}
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			slog.Warn("Lost connection to MQTT broker", "err", err)
+		})
+
+	client := paho.NewClient(opts)
+	client.Connect()
+	for _, zm := range zones {
+		zm.mu.Lock()
+		zm.mqtt = pahoPublisher{client: client}
+		zm.mu.Unlock()
+	}
+
+	<-ctx.Done()
+	for _, zm := range zones {
+		zm.mu.Lock()
+		zm.mqtt = nil
+		zm.mu.Unlock()
+	}
+	if client.IsConnectionOpen() {
+		client.Publish(topics.availability, 1, true, "offline").WaitTimeout(mqttTimeout)
+	}
+	client.Disconnect(uint(mqttTimeout.Milliseconds()))
+	commands.Wait()
+}
+
+// publishMQTTDiscovery announces the sensor and switch of the manager's zone to Home Assistant
+// and marks them available. The entities of a zone are prefixed with its name.
+func (hm *HeatingManager) publishMQTTDiscovery(publisher mqttPublisher) error {
+	config := hm.currentConfig()
+	topics := hm.mqttTopics()
+	nodeID := strings.ReplaceAll(config.MQTTTopicPrefix, "/", "_")
+	device := haDevice{Identifiers: []string{nodeID}, Name: "Heating Manager", SWVersion: version}
+	objectPrefix, namePrefix := "", ""
+	if hm.Zone != "" {
+		objectPrefix, namePrefix = hm.Zone+"_", hm.Zone+" "
+	}
+
+	messages := map[string]haDiscovery{
+		homeAssistantDiscoveryPrefix + "/sensor/" + nodeID + "/" + objectPrefix + "temperature/config": {
+			Name:              namePrefix + "Tank temperature",
+			UniqueID:          nodeID + "_" + objectPrefix + "temperature",
+			StateTopic:        topics.temperature,
+			AvailabilityTopic: topics.availability,
+			DeviceClass:       "temperature",
+			UnitOfMeasurement: "°" + config.TemperatureUnit,
+			Device:            device,
+		},
+		homeAssistantDiscoveryPrefix + "/switch/" + nodeID + "/" + objectPrefix + "heating/config": {
+			Name:              namePrefix + "Heating",
+			UniqueID:          nodeID + "_" + objectPrefix + "heating",
+			StateTopic:        topics.heatingState,
+			CommandTopic:      topics.heatingSet,
+			AvailabilityTopic: topics.availability,
+			Device:            device,
+		},
+	}
+	for topic, message := range messages {
+		payload, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to marshal discovery message: %v", err)
+		}
+		if err := publisher.Publish(topic, payload, true); err != nil {
+			return err
+		}
+	}
+	return publisher.Publish(topics.availability, []byte("online"), true)
+}
+
+// publishMQTTState publishes the temperature and heating state if MQTT is connected.
+// Failures are only logged.
+func (hm *HeatingManager) publishMQTTState(ctx context.Context, temperature float64) {
+	hm.mu.Lock()
+	publisher := hm.mqtt
+	heatingOn := hm.heatingOn
+	hm.mu.Unlock()
+	if publisher == nil {
+		return
+	}
+
+	config := hm.currentConfig()
+	topics := hm.mqttTopics()
+	state := mqttOff
+	if heatingOn {
+		state = mqttOn
+	}
+	if err := publisher.Publish(topics.temperature, []byte(config.formatTemperature(temperature)), true); err != nil {
+		slog.WarnContext(ctx, "Failed to publish temperature to MQTT", "err", err)
+		return
+	}
+	if err := publisher.Publish(topics.heatingState, []byte(state), true); err != nil {
+		slog.WarnContext(ctx, "Failed to publish heating state to MQTT", "err", err)
+	}
+}
+
+// handleMQTTCommand switches the heating from a Home Assistant switch command.
+// ON starts a supervised heating cycle like the weekly check, regardless of the minimum heating interval,
+// unless the tank exceeds the maximum safe temperature; OFF ends it.
+func (hm *HeatingManager) handleMQTTCommand(ctx context.Context, command string) {
+	ctx = withCorrelationID(ctx)
+	config := hm.currentConfig()
+	slog.InfoContext(ctx, "MQTT heating command received", "command", command)
+
+	var err error
+	switch command {
+	case mqttOn:
+		if temperature, _ := hm.CurrentTemperature(); config.MaxSafeTemperature != 0 && temperature > config.MaxSafeTemperature {
+			slog.WarnContext(ctx, "Refusing MQTT heating command, the tank exceeds the maximum safe temperature", "temperature", temperature, "maxSafeTemperature", config.MaxSafeTemperature, "unit", config.TemperatureUnit)
+			// Reset the switch in Home Assistant to the actual state.
+			hm.publishMQTTState(ctx, temperature)
+			return
+		}
+		err = hm.turnHeatingOn(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL), true)
+	case mqttOff:
+		hm.cancelHeatingOff()
+		err = hm.switchHeatingOff(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL))
+	default:
+		slog.WarnContext(ctx, "Ignoring unknown MQTT heating command", "command", command)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to switch heating from MQTT", "command", command, "err", err)
+		return
+	}
+
+	temperature, _ := hm.CurrentTemperature()
+	hm.publishMQTTState(ctx, temperature)
+}
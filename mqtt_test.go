@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingPublisher is an mqttPublisher remembering the last payload per topic.
+type recordingPublisher struct {
+	messages map[string]string
+}
+
+func (p *recordingPublisher) Publish(topic string, payload []byte, retained bool) error {
+	if p.messages == nil {
+		p.messages = map[string]string{}
+	}
+	p.messages[topic] = string(payload)
+	return nil
+}
+
+func TestPublishMQTTDiscovery(t *testing.T) {
+	manager := newTestManager(t)
+	publisher := &recordingPublisher{}
+	if err := manager.publishMQTTDiscovery(publisher); err != nil {
+		t.Fatalf("publishMQTTDiscovery returned an error: %v", err)
+	}
+
+	var sw haDiscovery
+	if err := json.Unmarshal([]byte(publisher.messages["homeassistant/switch/heating_manager/heating/config"]), &sw); err != nil {
+		t.Fatalf("Failed to decode switch discovery: %v", err)
+	}
+	if sw.CommandTopic != "heating_manager/heating/set" || sw.StateTopic != "heating_manager/heating" {
+		t.Errorf("Unexpected switch discovery: %+v", sw)
+	}
+	var sensor haDiscovery
+	if err := json.Unmarshal([]byte(publisher.messages["homeassistant/sensor/heating_manager/temperature/config"]), &sensor); err != nil {
+		t.Fatalf("Failed to decode sensor discovery: %v", err)
+	}
+	if sensor.DeviceClass != "temperature" || sensor.UnitOfMeasurement != "°C" {
+		t.Errorf("Unexpected sensor discovery: %+v", sensor)
+	}
+	if publisher.messages["heating_manager/status"] != "online" {
+		t.Error("Expected the device to be announced online")
+	}
+}
+
+func TestHandleMQTTCommand(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+	publisher := &recordingPublisher{}
+	manager.mqtt = publisher
+	defer manager.cancelHeatingOff()
+
+	manager.handleMQTTCommand(context.Background(), mqttOn)
+	if publisher.messages["heating_manager/heating"] != mqttOn {
+		t.Errorf("Expected heating state ON, got %q", publisher.messages["heating_manager/heating"])
+	}
+
+	manager.handleMQTTCommand(context.Background(), mqttOff)
+	if publisher.messages["heating_manager/heating"] != mqttOff {
+		t.Errorf("Expected heating state OFF, got %q", publisher.messages["heating_manager/heating"])
+	}
+	if manager.cancelHeating != nil {
+		t.Error("Expected the heating cycle to be cancelled")
+	}
+}
+
+func TestHandleMQTTCommandMaxSafeTemperature(t *testing.T) {
+	var onCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/on" {
+			onCalls++
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+	manager.Config.MaxSafeTemperature = 80
+	manager.LastTemperature = 82
+	publisher := &recordingPublisher{}
+	manager.mqtt = publisher
+
+	manager.handleMQTTCommand(context.Background(), mqttOn)
+	if onCalls != 0 || manager.cancelHeating != nil {
+		t.Errorf("Expected no heating cycle above the maximum safe temperature, got %d on calls", onCalls)
+	}
+	if publisher.messages["heating_manager/heating"] != mqttOff {
+		t.Errorf("Expected the heating state to be reset to OFF, got %q", publisher.messages["heating_manager/heating"])
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Notification events.
+const (
+	eventHeatingOn          = "heating_on"
+	eventHeatingOff         = "heating_off"
+	eventHeatingSkipped     = "heating_skipped"
+	eventReadFailures       = "temperature_read_failures"
+	eventReadRecovered      = "temperature_read_recovered"
+	eventSafetyCutoff       = "safety_cutoff"
+	eventHeatingUnconfirmed = "heating_on_unconfirmed"
+	eventHeatingNoPower     = "heating_no_power"
+	eventHeatingNotVerified = "heating_not_verified"
+	eventHeatingFailed      = "heating_failed"
+	eventHeatingOffFailed   = "heating_off_failed"
+	eventServiceStarted     = "service_started"
+	eventServiceStopped     = "service_stopped"
+)
+
+// Notification reasons.
+const (
+	reasonWeeklyLegionella   = "weekly_legionella"
+	reasonThresholdExceeded  = "threshold_exceeded"
+	reasonRepeatedFailures   = "repeated_failures"
+	reasonDeviceUnreachable  = "device_unreachable"
+	reasonBadStatus          = "bad_status"
+	reasonInvalidResponse    = "invalid_response"
+	reasonMaxSafeTemperature = "max_safe_temperature"
+	reasonRelayOff           = "relay_off"
+	reasonNoPowerDraw        = "no_power_draw"
+	reasonTargetNotReached   = "target_not_reached"
+	reasonVerifyReadFailed   = "verification_read_failed"
+	reasonHeatingCycleEnded  = "heating_cycle_ended"
+	reasonMinHeatingInterval = "min_heating_interval"
+	reasonHeatingInProgress  = "heating_in_progress"
+	reasonPasteurized        = "pasteurized"
+	reasonManualSkip         = "manual_skip"
+	reasonStartup            = "startup"
+	reasonShutdown           = "graceful_shutdown"
+)
+
+// Notification severities, in increasing order.
+const (
+	severityInfo     = "info"
+	severityWarning  = "warning"
+	severityCritical = "critical"
+)
+
+// severityRanks orders the notification severities.
+var severityRanks = map[string]int{
+	severityInfo:     0,
+	severityWarning:  1,
+	severityCritical: 2,
+}
+
+// eventSeverities are the severities of the notification events; events not listed are info.
+var eventSeverities = map[string]string{
+	eventReadFailures:       severityWarning,
+	eventHeatingUnconfirmed: severityWarning,
+	eventHeatingNoPower:     severityWarning,
+	eventSafetyCutoff:       severityCritical,
+	eventHeatingNotVerified: severityCritical,
+	eventHeatingFailed:      severityCritical,
+	eventHeatingOffFailed:   severityCritical,
+}
+
+// eventSeverity returns the severity of a notification event.
+func eventSeverity(event string) string {
+	if severity, ok := eventSeverities[event]; ok {
+		return severity
+	}
+	return severityInfo
+}
+
+// Notification is the JSON body posted to the notification webhook.
+type Notification struct {
+	Event     string    `json:"event"`
+	Severity  string    `json:"severity"`
+	Time      time.Time `json:"time"`
+	Reason    string    `json:"reason"`
+	Version   string    `json:"version,omitempty"`   // Version of the manager, set on service events.
+	Threshold float64   `json:"threshold,omitempty"` // Loaded temperature threshold, set on service start.
+	Zone      string    `json:"zone,omitempty"`      // Zone the event happened in, empty for a single-zone config.
+}
+
+// Message returns a human-readable description of the notification, prefixed with its zone if set.
+func (n Notification) Message() string {
+	if n.Zone != "" {
+		return n.Zone + ": " + n.message()
+	}
+	return n.message()
+}
+
+// message describes the notification event.
+func (n Notification) message() string {
+	switch n.Event {
+	case eventHeatingOn:
+		return "Legionella heating turned on."
+	case eventHeatingSkipped:
+		switch n.Reason {
+		case reasonMinHeatingInterval:
+			return "Legionella heating skipped, the heating ran only recently."
+		case reasonPasteurized:
+			return "Legionella heating skipped, the tank was already pasteurized this week."
+		case reasonManualSkip:
+			return "Legionella heating skipped on request."
+		case reasonHeatingInProgress:
+			return "Legionella heating skipped, a heating run is already in progress."
+		}
+		return "Legionella heating skipped, the temperature threshold was already exceeded."
+	case eventReadFailures:
+		return "Reading the temperature failed repeatedly."
+	case eventReadRecovered:
+		return "Temperature readings recovered."
+	case eventHeatingUnconfirmed:
+		return "Heating on command was accepted, but the relay did not switch on."
+	case eventHeatingNoPower:
+		return "Heating relay is on, but the heating element draws no power."
+	case eventHeatingNotVerified:
+		if n.Reason == reasonTargetNotReached {
+			return "Legionella heating ran, but the tank did not reach the target temperature."
+		}
+		return "Legionella heating ran, but the tank temperature could not be read to verify it."
+	case eventHeatingFailed:
+		return "Legionella heating could not be turned on, the weekly check will be retried."
+	case eventHeatingOffFailed:
+		return "Heating could not be turned off, retrying at every temperature check."
+	case eventServiceStarted:
+		return fmt.Sprintf("Heating manager %s started with a temperature threshold of %g.", n.Version, n.Threshold)
+	case eventServiceStopped:
+		return fmt.Sprintf("Heating manager %s shut down.", n.Version)
+	case eventSafetyCutoff:
+		return "Tank temperature exceeded the maximum safe temperature, heating was forced off."
+	default:
+		return fmt.Sprintf("Heating manager event %s (%s).", n.Event, n.Reason)
+	}
+}
+
+// notify sends a notification about an event to all configured channels.
+// Channels without configuration are skipped; failures are only logged, with the correlation ID of ctx.
+func (hm *HeatingManager) notify(ctx context.Context, event, reason string) {
+	hm.send(ctx, Notification{Event: event, Time: hm.Clock.Now(), Reason: reason})
+}
+
+// notifyService sends a service start or stop notification with the version and the loaded threshold.
+func (hm *HeatingManager) notifyService(ctx context.Context, event, reason string) {
+	notification := Notification{Event: event, Time: hm.Clock.Now(), Reason: reason, Version: version}
+	if event == eventServiceStarted {
+		notification.Threshold = hm.currentConfig().TemperatureThreshold
+	}
+	hm.send(ctx, notification)
+}
+
+// send delivers a notification to all configured channels that accept its severity.
+func (hm *HeatingManager) send(ctx context.Context, notification Notification) {
+	config := hm.currentConfig()
+	event := notification.Event
+	notification.Zone = hm.Zone
+	if notification.Severity == "" {
+		notification.Severity = eventSeverity(event)
+	}
+
+	if config.NotifyURL != "" && acceptsNotification(config.NotifyMinSeverity, nil, notification) {
+		if err := hm.postWebhook(notification); err != nil {
+			slog.WarnContext(ctx, "Failed to send webhook notification", "event", event, "err", err)
+		}
+	}
+	if config.TelegramBotToken != "" && acceptsNotification(config.TelegramMinSeverity, nil, notification) {
+		if err := hm.sendTelegram(notification.Message()); err != nil {
+			slog.WarnContext(ctx, "Failed to send Telegram notification", "event", event, "err", err)
+		}
+	}
+	if config.SlackWebhookURL != "" && acceptsNotification(config.SlackMinSeverity, slackEvents, notification) {
+		if err := hm.sendSlack(notification); err != nil {
+			slog.WarnContext(ctx, "Failed to send Slack notification", "event", event, "err", err)
+		}
+	}
+	if config.SMTPHost != "" && acceptsNotification(config.EmailMinSeverity, emailEvents, notification) {
+		if err := hm.sendEmail(notification); err != nil {
+			slog.WarnContext(ctx, "Failed to send email notification", "event", event, "err", err)
+		}
+	}
+}
+
+// acceptsNotification reports whether a channel with the given minimum severity receives a notification.
+// Without a minimum severity, the channel receives its default events, or all events if defaultEvents is nil.
+func acceptsNotification(minSeverity string, defaultEvents map[string]bool, notification Notification) bool {
+	if minSeverity == "" {
+		return defaultEvents == nil || defaultEvents[notification.Event]
+	}
+	return severityRanks[notification.Severity] >= severityRanks[minSeverity]
+}
+
+// postWebhook posts a notification as JSON to the configured webhook.
+func (hm *HeatingManager) postWebhook(notification Notification) error {
+	config := hm.currentConfig()
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %v", err)
+	}
+
+	resp, err := hm.httpPost(config.NotifyURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post notification: status code %d", resp.StatusCode)
+	}
+	return nil
+}
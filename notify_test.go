@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	var received Notification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.NotifyURL = ts.URL
+
+	manager.notify(context.Background(), eventHeatingOn, reasonWeeklyLegionella)
+	if received.Event != eventHeatingOn || received.Reason != reasonWeeklyLegionella {
+		t.Errorf("Unexpected notification: %+v", received)
+	}
+	if !received.Time.Equal(clock.Now()) {
+		t.Errorf("Expected the notification time %v of the manager's clock, got %v", clock.Now(), received.Time)
+	}
+	if received.Severity != severityInfo {
+		t.Errorf("Expected severity %q, got %q", severityInfo, received.Severity)
+	}
+}
+
+func TestNotifyMinSeverity(t *testing.T) {
+	var received []Notification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		received = append(received, notification)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = ts.URL
+	manager.Config.NotifyMinSeverity = severityWarning
+
+	manager.notify(context.Background(), eventHeatingOn, reasonWeeklyLegionella)
+	manager.notify(context.Background(), eventReadFailures, reasonRepeatedFailures)
+	manager.notify(context.Background(), eventSafetyCutoff, reasonMaxSafeTemperature)
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 notifications at or above warning, got %d", len(received))
+	}
+	if received[0].Severity != severityWarning || received[1].Severity != severityCritical {
+		t.Errorf("Unexpected severities %q and %q", received[0].Severity, received[1].Severity)
+	}
+}
+
+func TestAcceptsNotification(t *testing.T) {
+	defaults := map[string]bool{eventHeatingOn: true}
+	tests := []struct {
+		name        string
+		minSeverity string
+		defaults    map[string]bool
+		event       string
+		want        bool
+	}{
+		{"all events by default", "", nil, eventHeatingSkipped, true},
+		{"default event", "", defaults, eventHeatingOn, true},
+		{"non-default event", "", defaults, eventSafetyCutoff, false},
+		{"severity replaces defaults", severityCritical, defaults, eventSafetyCutoff, true},
+		{"below minimum severity", severityCritical, defaults, eventHeatingOn, false},
+	}
+	for _, tt := range tests {
+		notification := Notification{Event: tt.event, Severity: eventSeverity(tt.event)}
+		if got := acceptsNotification(tt.minSeverity, tt.defaults, notification); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestNotifyService(t *testing.T) {
+	var received []Notification
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var notification Notification
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		received = append(received, notification)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = ts.URL
+
+	manager.notifyService(context.Background(), eventServiceStarted, reasonStartup)
+	manager.notifyService(context.Background(), eventServiceStopped, reasonShutdown)
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(received))
+	}
+	started, stopped := received[0], received[1]
+	if started.Event != eventServiceStarted || started.Version != version || started.Threshold != manager.Config.TemperatureThreshold {
+		t.Errorf("Unexpected start notification: %+v", started)
+	}
+	if stopped.Event != eventServiceStopped || stopped.Reason != reasonShutdown || stopped.Threshold != 0 {
+		t.Errorf("Unexpected stop notification: %+v", stopped)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// Checks that can be run with the -once flag.
+const (
+	onceTemperature = "temp"
+	onceWeekly      = "weekly"
+)
+
+// runOnce runs a single temperature or weekly check, for scheduling from cron or systemd timers.
+// A weekly check that turns the heating on waits for the heating cycle and its verification to finish,
+// so the heating is not left on when the process exits. Cancelling the context ends the cycle early
+// and turns the heating off. A heating cycle interrupted by a previous run is resumed and waited for as well.
+func (hm *HeatingManager) runOnce(ctx context.Context, checkType string) error {
+	config := hm.currentConfig()
+	controller := hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)
+	switch checkType {
+	case onceTemperature:
+		hm.resumeHeatingOff(ctx)
+		err := hm.checkTemperature(ctx, config.ShellyURLs)
+		hm.waitForHeating(ctx, controller)
+		return err
+	case onceWeekly:
+		hm.resumeHeatingOff(ctx)
+		err := hm.weeklyCheck(ctx, controller, false)
+		hm.waitForHeating(ctx, controller)
+		return err
+	default:
+		return fmt.Errorf("unknown check type %q, expected %q or %q", checkType, onceTemperature, onceWeekly)
+	}
+}
+
+// waitForHeating waits until the running heating cycle, if any, has finished.
+// If the context is cancelled first, the cycle is abandoned and the heating turned off.
+func (hm *HeatingManager) waitForHeating(ctx context.Context, controller HeatingController) {
+	done := make(chan struct{})
+	go func() {
+		hm.heatingWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	hm.cancelHeatingOff()
+	<-done
+	hm.mu.Lock()
+	heatingOn := hm.heatingOn
+	hm.mu.Unlock()
+	if !heatingOn {
+		return
+	}
+	slog.InfoContext(ctx, "Interrupted while heating, turning off heating")
+	if err := hm.switchHeatingOff(detach(ctx), controller); err != nil {
+		slog.ErrorContext(ctx, "Failed to turn off heating", "err", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunOnceTemperature(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{"http://shelly/temp"}
+	manager.HTTPClient = stubResponse(http.StatusOK, `{"id":0,"tC":48.5}`)
+	if err := manager.runOnce(context.Background(), onceTemperature); err != nil {
+		t.Errorf("runOnce returned an error: %v", err)
+	}
+	if temperature, _ := manager.CurrentTemperature(); temperature != 48.5 {
+		t.Errorf("Expected temperature 48.5, got %v", temperature)
+	}
+
+	manager.HTTPClient = stubResponse(http.StatusServiceUnavailable, "")
+	manager.Config.RetryBackoff = 1
+	if err := manager.runOnce(context.Background(), onceTemperature); err == nil {
+		t.Error("Expected runOnce to fail when the sensor cannot be read")
+	}
+}
+
+func TestRunOnceWeeklyWaitsForHeating(t *testing.T) {
+	var offCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/off" {
+			offCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+	manager.Config.HeatingDurationMinutes = 0
+
+	if err := manager.runOnce(context.Background(), onceWeekly); err != nil {
+		t.Fatalf("runOnce returned an error: %v", err)
+	}
+	if offCalls.Load() != 1 {
+		t.Errorf("Expected the heating to be turned off before returning, got %d off calls", offCalls.Load())
+	}
+}
+
+func TestRunOnceWeeklyInterrupted(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.ShellyHeatingOnURL = "http://shelly/on"
+	manager.Config.ShellyHeatingOffURL = "http://shelly/off"
+
+	// The context is cancelled by the on call, so runOnce is interrupted while waiting for the heating cycle.
+	ctx, cancel := context.WithCancel(context.Background())
+	var offCalls atomic.Int32
+	manager.HTTPClient = &stubHTTPClient{do: func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/on":
+			cancel()
+		case "/off":
+			offCalls.Add(1)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}
+	if err := manager.runOnce(ctx, onceWeekly); err != nil {
+		t.Fatalf("runOnce returned an error: %v", err)
+	}
+	if offCalls.Load() != 1 {
+		t.Errorf("Expected the heating to be turned off when interrupted, got %d off calls", offCalls.Load())
+	}
+}
+
+func TestRunOnceUnknownCheckType(t *testing.T) {
+	manager := newTestManager(t)
+	if err := manager.runOnce(context.Background(), "monthly"); err == nil {
+		t.Error("Expected an error for an unknown check type")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Results of a weekly check.
+const (
+	outcomeHeated  = "heated"
+	outcomeSkipped = "skipped"
+	outcomeFailed  = "failed"
+)
+
+// weeklyOutcomeHistorySize is the number of weekly check outcomes kept in the state file.
+const weeklyOutcomeHistorySize = 20
+
+// WeeklyOutcome is the result of a weekly check.
+type WeeklyOutcome struct {
+	Time   time.Time `json:"time"`            // Time the check ran.
+	Result string    `json:"result"`          // outcomeHeated, outcomeSkipped or outcomeFailed.
+	Reason string    `json:"reason"`          // Notification reason of the heating run or skip.
+	Error  string    `json:"error,omitempty"` // Error of a failed check.
+}
+
+// recordWeeklyOutcome appends a weekly check outcome, dropping the oldest beyond weeklyOutcomeHistorySize.
+// The outcome is persisted with the next state write.
+func (hm *HeatingManager) recordWeeklyOutcome(ctx context.Context, result, reason string, err error) {
+	outcome := WeeklyOutcome{Time: hm.Clock.Now(), Result: result, Reason: reason}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	hm.mu.Lock()
+	hm.weeklyOutcomes = append(hm.weeklyOutcomes, outcome)
+	if len(hm.weeklyOutcomes) > weeklyOutcomeHistorySize {
+		hm.weeklyOutcomes = hm.weeklyOutcomes[len(hm.weeklyOutcomes)-weeklyOutcomeHistorySize:]
+	}
+	hm.mu.Unlock()
+	slog.DebugContext(ctx, "Recorded weekly check outcome", "result", result, "reason", reason)
+}
+
+// LastWeeklyOutcome returns the most recent weekly check outcome, or nil if none was recorded.
+func (hm *HeatingManager) LastWeeklyOutcome() *WeeklyOutcome {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if len(hm.weeklyOutcomes) == 0 {
+		return nil
+	}
+	outcome := hm.weeklyOutcomes[len(hm.weeklyOutcomes)-1]
+	return &outcome
+}
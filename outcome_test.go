@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWeeklyCheckRecordsOutcome(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.RetryBackoff = 1
+	defer manager.cancelHeatingOff()
+	if manager.LastWeeklyOutcome() != nil {
+		t.Fatal("Expected no outcome before the first weekly check")
+	}
+
+	status = http.StatusInternalServerError
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err == nil {
+		t.Fatal("Expected weeklyCheck to fail")
+	}
+	if outcome := manager.LastWeeklyOutcome(); outcome == nil || outcome.Result != outcomeFailed || outcome.Error == "" {
+		t.Errorf("Expected a failed outcome with an error, got %+v", outcome)
+	}
+
+	status = http.StatusOK
+	manager.setTemperatureExceeded(true)
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if outcome := manager.LastWeeklyOutcome(); outcome == nil || outcome.Result != outcomeSkipped || outcome.Reason != reasonThresholdExceeded {
+		t.Errorf("Expected a skipped outcome, got %+v", outcome)
+	}
+
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if outcome := manager.LastWeeklyOutcome(); outcome == nil || outcome.Result != outcomeHeated {
+		t.Errorf("Expected a heated outcome, got %+v", outcome)
+	}
+
+	// The outcomes survive a restart.
+	restarted := newTestManager(t)
+	restarted.StateFile = manager.StateFile
+	restarted.restoreState()
+	if len(restarted.weeklyOutcomes) != 3 {
+		t.Errorf("Expected 3 restored outcomes, got %d", len(restarted.weeklyOutcomes))
+	}
+}
+
+func TestRecordWeeklyOutcomeLimit(t *testing.T) {
+	manager := newTestManager(t)
+	for i := 0; i < weeklyOutcomeHistorySize+5; i++ {
+		manager.recordWeeklyOutcome(context.Background(), outcomeHeated, reasonWeeklyLegionella, nil)
+	}
+	if len(manager.weeklyOutcomes) != weeklyOutcomeHistorySize {
+		t.Errorf("Expected %d outcomes, got %d", weeklyOutcomeHistorySize, len(manager.weeklyOutcomes))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import "time"
+
+// trackPasteurization adds the time since the previous reading to the pasteurized time
+// if both readings were at or above PasteurizationTemp. Readings further apart than two
+// poll intervals are not counted, so an outage does not count as time at temperature.
+func (hm *HeatingManager) trackPasteurization(temperature float64) {
+	config := hm.currentConfig()
+	if config.PasteurizationTemp == 0 {
+		return
+	}
+	now := hm.Clock.Now()
+	maxGap := 2 * hm.pollInterval()
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if temperature < config.PasteurizationTemp {
+		hm.lastPasteurizationRead = time.Time{}
+		return
+	}
+	if gap := now.Sub(hm.lastPasteurizationRead); !hm.lastPasteurizationRead.IsZero() && gap <= maxGap {
+		hm.pasteurizedDuration += gap
+	}
+	hm.lastPasteurizationRead = now
+}
+
+// isPasteurized reports whether the tank stayed at or above PasteurizationTemp for at least
+// PasteurizationMinutes since the last weekly check.
+func (hm *HeatingManager) isPasteurized() bool {
+	required := time.Duration(hm.currentConfig().PasteurizationMinutes) * time.Minute
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.pasteurizedDuration >= required
+}
+
+// resetPasteurization clears the pasteurized time after a weekly check or a heating run.
+// Callers persist the change with saveState.
+func (hm *HeatingManager) resetPasteurization() {
+	hm.mu.Lock()
+	hm.pasteurizedDuration = 0
+	hm.lastPasteurizationRead = time.Time{}
+	hm.mu.Unlock()
+}
+
+// weeklyHeatingSkipReason returns the notification reason for skipping the weekly heating,
+// or an empty string if the heating is needed. With PasteurizationTemp set, the heating is
+// only skipped after a sustained pasteurization; otherwise a single reading above the
+// threshold suffices.
+func (hm *HeatingManager) weeklyHeatingSkipReason() string {
+	if hm.currentConfig().PasteurizationTemp != 0 {
+		if hm.isPasteurized() {
+			return reasonPasteurized
+		}
+		return ""
+	}
+	if hm.isTemperatureExceeded() {
+		return reasonThresholdExceeded
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTrackPasteurization(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.PasteurizationTemp = 60
+	manager.Config.PasteurizationMinutes = 30
+	interval := manager.checkInterval()
+
+	// Four readings above the temperature span three check intervals.
+	for i := 0; i < 4; i++ {
+		manager.trackPasteurization(61)
+		clock.Advance(interval)
+	}
+	// A reading below ends the span, the following one starts a new span.
+	manager.trackPasteurization(55)
+	clock.Advance(interval)
+	manager.trackPasteurization(62)
+	// An outage longer than two check intervals is not counted.
+	clock.Advance(3 * interval)
+	manager.trackPasteurization(62)
+
+	if want := 3 * interval; manager.pasteurizedDuration != want {
+		t.Errorf("Expected %v above the pasteurization temperature, got %v", want, manager.pasteurizedDuration)
+	}
+	manager.Config.PasteurizationMinutes = int((3 * interval).Minutes())
+	if !manager.isPasteurized() {
+		t.Error("Expected the tank to count as pasteurized")
+	}
+	manager.Config.PasteurizationMinutes++
+	if manager.isPasteurized() {
+		t.Error("Expected the tank not to count as pasteurized")
+	}
+}
+
+func TestWeeklyCheckPasteurization(t *testing.T) {
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/on" {
+			onCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.PasteurizationTemp = 60
+	manager.Config.PasteurizationMinutes = 30
+	defer manager.cancelHeatingOff()
+
+	// A single reading above the threshold no longer skips the heating.
+	manager.setTemperatureExceeded(true)
+	manager.pasteurizedDuration = 10 * time.Minute
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected heating after a too short pasteurization, got %d on calls", onCalls.Load())
+	}
+	if manager.pasteurizedDuration != 0 {
+		t.Errorf("Expected the pasteurized time to be reset, got %v", manager.pasteurizedDuration)
+	}
+
+	manager.cancelHeatingOff()
+	manager.pasteurizedDuration = 45 * time.Minute
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected the heating to be skipped after a sustained pasteurization, got %d on calls", onCalls.Load())
+	}
+}
+
+func TestWeeklyRunDoesNotCountAsPasteurization(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.PasteurizationTemp = 60
+	manager.Config.PasteurizationMinutes = 30
+	controller := &stubController{}
+	manager.HeatingController = controller
+
+	if err := manager.weeklyCheck(context.Background(), controller, false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.cancelHeatingOff()
+	manager.heatingWG.Wait()
+	// The run heats the tank above the pasteurization temperature for an hour before it ends.
+	for i := 0; i <= 12; i++ {
+		manager.trackPasteurization(65)
+		clock.Advance(manager.checkInterval())
+	}
+	manager.turnHeatingOff(context.Background(), controller)
+
+	clock.Advance(7 * 24 * time.Hour)
+	if err := manager.weeklyCheck(context.Background(), controller, false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.cancelHeatingOff()
+	if calls := controller.onCalls.Load(); calls != 2 {
+		t.Errorf("Expected the next weekly check to heat despite the last run's pasteurization, got %d on calls", calls)
+	}
+}
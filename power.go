@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Timing of the power confirmation after switching the heating on.
+var (
+	powerConfirmTimeout = time.Minute
+	powerPollInterval   = 5 * time.Second
+)
+
+// powerStatus is the power draw reported by Shelly devices,
+// "apower" for Gen2 Switch.GetStatus and "power" for the Gen1 meter endpoint.
+type powerStatus struct {
+	APower *float64 `json:"apower"`
+	Power  *float64 `json:"power"`
+}
+
+// getPower reads the power draw in watts of the heating element.
+func (hm *HeatingManager) getPower(ctx context.Context, shellyPowerURL string) (float64, error) {
+	resp, err := hm.shellyGet(ctx, shellyPowerURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get power: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get power: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+	var status powerStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal power status: %w: %v", ErrParse, err)
+	}
+	switch {
+	case status.APower != nil:
+		return *status.APower, nil
+	case status.Power != nil:
+		return *status.Power, nil
+	default:
+		return 0, fmt.Errorf("failed to get power: no apower or power field in response")
+	}
+}
+
+// confirmHeatingPower polls the power draw until it exceeds MinHeatingWatts or powerConfirmTimeout passes.
+// A heating element that draws no power sends a notification; cancelling the context ends the check silently.
+func (hm *HeatingManager) confirmHeatingPower(ctx context.Context, shellyPowerURL string) {
+	minWatts := hm.currentConfig().MinHeatingWatts
+	deadline := time.NewTimer(powerConfirmTimeout)
+	defer deadline.Stop()
+
+	var watts float64
+	for {
+		power, err := hm.getPower(ctx, shellyPowerURL)
+		if err == nil {
+			watts = power
+			if watts > minWatts {
+				slog.InfoContext(ctx, "Heating element confirmed drawing power", "watts", watts, "minWatts", minWatts)
+				return
+			}
+		} else {
+			slog.DebugContext(ctx, "Failed to read power", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline.C:
+			slog.ErrorContext(ctx, "Heating relay is on, but the heating element draws no power", "event", eventHeatingNoPower, "watts", watts, "minWatts", minWatts, "timeout", powerConfirmTimeout)
+			hm.notify(ctx, eventHeatingNoPower, reasonNoPowerDraw)
+			return
+		case <-time.After(powerPollInterval):
+		}
+	}
+}
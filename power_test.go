@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConfirmHeatingPower(t *testing.T) {
+	oldTimeout, oldInterval := powerConfirmTimeout, powerPollInterval
+	powerConfirmTimeout, powerPollInterval = 50*time.Millisecond, 5*time.Millisecond
+	defer func() { powerConfirmTimeout, powerPollInterval = oldTimeout, oldInterval }()
+
+	tests := []struct {
+		name     string
+		body     string
+		wantSent bool
+	}{
+		{"gen2 heating", `{"id": 0, "output": true, "apower": 2000.5}`, false},
+		{"gen1 heating", `{"power": 1800, "is_valid": true}`, false},
+		{"element broken", `{"id": 0, "output": true, "apower": 1.2}`, true},
+		{"unexpected response", `{"id": 0}`, true},
+	}
+	for _, tt := range tests {
+		var notified bool
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/notify" {
+				notified = true
+				return
+			}
+			_, _ = w.Write([]byte(tt.body))
+		}))
+
+		manager := newTestManager(t)
+		manager.Config.NotifyURL = ts.URL + "/notify"
+		manager.Config.MinHeatingWatts = 100
+		manager.confirmHeatingPower(context.Background(), ts.URL+"/power")
+		ts.Close()
+
+		if notified != tt.wantSent {
+			t.Errorf("%s: expected notification %v, got %v", tt.name, tt.wantSent, notified)
+		}
+	}
+}
+
+func TestConfirmHeatingPowerCancelled(t *testing.T) {
+	var notified bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notify" {
+			notified = true
+			return
+		}
+		_, _ = w.Write([]byte(`{"apower": 0}`))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.NotifyURL = ts.URL + "/notify"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	manager.confirmHeatingPower(ctx, ts.URL+"/power")
+	if notified {
+		t.Error("Expected no notification once the heating cycle ended")
+	}
+}
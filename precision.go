@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math"
+	"strconv"
+)
+
+// maxTemperaturePrecision is the highest supported number of decimal places for temperatures.
+const maxTemperaturePrecision = 6
+
+// precision returns the configured number of decimal places for temperatures, 0 rounding to whole degrees.
+func (c *Config) precision() int {
+	if c.TemperaturePrecision == nil {
+		return defaultTemperaturePrecision
+	}
+	return *c.TemperaturePrecision
+}
+
+// roundTemperature rounds a temperature to TemperaturePrecision decimal places, so logs, the API,
+// the dashboard and metrics all report the same value.
+func (c *Config) roundTemperature(temperature float64) float64 {
+	scale := math.Pow(10, float64(c.precision()))
+	return math.Round(temperature*scale) / scale
+}
+
+// formatTemperature formats a temperature with exactly TemperaturePrecision decimal places.
+func (c *Config) formatTemperature(temperature float64) string {
+	return strconv.FormatFloat(c.roundTemperature(temperature), 'f', c.precision(), 64)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormatTemperature(t *testing.T) {
+	tests := []struct {
+		precision   int
+		temperature float64
+		rounded     float64
+		formatted   string
+	}{
+		{0, 54.56, 55, "55"},
+		{1, 54.56, 54.6, "54.6"},
+		{1, 55, 55, "55.0"},
+		{2, 54.555, 54.56, "54.56"},
+		{3, -1.23449, -1.234, "-1.234"},
+	}
+	for _, tt := range tests {
+		config := Config{TemperaturePrecision: ptr(tt.precision)}
+		if got := config.roundTemperature(tt.temperature); got != tt.rounded {
+			t.Errorf("roundTemperature(%v) with precision %d = %v, want %v", tt.temperature, tt.precision, got, tt.rounded)
+		}
+		if got := config.formatTemperature(tt.temperature); got != tt.formatted {
+			t.Errorf("formatTemperature(%v) with precision %d = %q, want %q", tt.temperature, tt.precision, got, tt.formatted)
+		}
+	}
+}
+
+func TestTemperaturePrecisionDefault(t *testing.T) {
+	var config Config
+	config.setDefaults()
+	if got := config.precision(); got != defaultTemperaturePrecision {
+		t.Errorf("Expected the default precision %d, got %d", defaultTemperaturePrecision, got)
+	}
+
+	config = Config{}
+	if err := json.Unmarshal([]byte(`{"temperaturePrecision": 0}`), &config); err != nil {
+		t.Fatalf("Failed to decode config: %v", err)
+	}
+	config.setDefaults()
+	if got := config.precision(); got != 0 {
+		t.Errorf("Expected a configured precision of 0 to round to whole degrees, got %d", got)
+	}
+}
+
+func TestCheckTemperatureRoundsReading(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.TemperaturePrecision = ptr(2)
+	manager.TemperatureSources = []TemperatureSource{stubSource{temperature: 54.5678}}
+
+	if err := manager.checkTemperature(context.Background(), manager.Config.ShellyURLs); err != nil {
+		t.Fatalf("checkTemperature returned an error: %v", err)
+	}
+	if manager.LastTemperature != 54.57 {
+		t.Errorf("Expected the reading rounded to 54.57, got %v", manager.LastTemperature)
+	}
+	if readings := manager.RecentReadings(0); len(readings) != 1 || readings[0].Temperature != 54.57 {
+		t.Errorf("Expected the recorded reading rounded to 54.57, got %v", readings)
+	}
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "54.57 °C") || !strings.Contains(body, "55.00 °C") {
+		t.Errorf("Expected the dashboard to show two decimals, got %q", body)
+	}
+}
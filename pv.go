@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StartPVSurplusControl turns the heating on while the PV surplus exceeds the configured threshold
+// and off again once it drops below. It returns when the context is cancelled and does nothing
+// if no PV surplus URL is configured.
+func (hm *HeatingManager) StartPVSurplusControl(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.PVSurplusURL == "" {
+		return
+	}
+
+	interval := hm.checkInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		configChanged := hm.configChangedChan()
+		select {
+		case <-ctx.Done():
+			return
+		case <-configChanged:
+			if newInterval := hm.checkInterval(); newInterval != interval {
+				interval = newInterval
+				ticker.Reset(interval)
+			}
+		case <-ticker.C:
+			hm.controlPVSurplus(ctx)
+		}
+	}
+}
+
+// controlPVSurplus reads the PV surplus once and switches the heating accordingly.
+// A running weekly legionella cycle is left alone. In vacation mode the surplus is not read
+// and heating started by it is turned off.
+func (hm *HeatingManager) controlPVSurplus(ctx context.Context) {
+	ctx = withCorrelationID(ctx)
+	config := hm.currentConfig()
+	if hm.isVacationMode() {
+		hm.suspendPVHeating(ctx, config)
+		return
+	}
+	surplus, err := hm.getPVSurplus(ctx, config.PVSurplusURL)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get PV surplus", "err", err)
+		return
+	}
+
+	hm.mu.Lock()
+	legionellaRunning := hm.cancelHeating != nil
+	pvHeating := hm.pvHeating
+	tooHot := config.MaxSafeTemperature != 0 && hm.LastTemperature > config.MaxSafeTemperature
+	hm.mu.Unlock()
+	if legionellaRunning {
+		return
+	}
+
+	switch {
+	case !pvHeating && surplus > config.PVSurplusThresholdWatts:
+		if tooHot {
+			slog.WarnContext(ctx, "PV surplus available, but the tank exceeds the maximum safe temperature", "surplus", surplus, "threshold", config.PVSurplusThresholdWatts)
+			return
+		}
+		if low, soc := hm.batteryLow(ctx); low {
+			slog.InfoContext(ctx, "PV surplus available, but deferring heating while the battery state of charge is below minimum", "surplus", surplus, "soc", soc, "minSOC", config.MinBatterySOC)
+			return
+		}
+		hm.startPVHeating(ctx, config, surplus)
+	case pvHeating && surplus < config.PVSurplusThresholdWatts:
+		slog.InfoContext(ctx, "PV surplus dropped below threshold, turning off heating", "surplus", surplus, "threshold", config.PVSurplusThresholdWatts)
+		if err := hm.switchHeatingOff(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)); err != nil {
+			slog.ErrorContext(ctx, "Failed to turn off heating after PV surplus", "err", err)
+			return
+		}
+		hm.setPVHeating(false)
+	}
+}
+
+// startPVHeating turns on the heating for PV surplus with the guards of turnHeatingOn: it does nothing
+// while another heating run is being started or its cycle is running, or within MinHeatingIntervalHours
+// of the last run.
+func (hm *HeatingManager) startPVHeating(ctx context.Context, config Config, surplus float64) {
+	if !hm.heatingMu.TryLock() {
+		slog.InfoContext(ctx, "PV surplus available, but another heating run is being started", "surplus", surplus)
+		return
+	}
+	defer hm.heatingMu.Unlock()
+	hm.mu.Lock()
+	running := hm.cancelHeating != nil
+	hm.mu.Unlock()
+	if running {
+		return
+	}
+	if lastHeatingRun, minInterval, tooSoon := hm.heatingTooSoon(); tooSoon {
+		slog.InfoContext(ctx, "PV surplus available, but the previous heating run was too recent", "surplus", surplus, "lastHeatingRun", lastHeatingRun, "minInterval", minInterval)
+		return
+	}
+
+	slog.InfoContext(ctx, "PV surplus exceeds threshold, turning on heating", "surplus", surplus, "threshold", config.PVSurplusThresholdWatts)
+	if err := hm.switchHeatingOn(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)); err != nil {
+		slog.ErrorContext(ctx, "Failed to turn on heating for PV surplus", "err", err)
+		return
+	}
+	hm.setPVHeating(true)
+}
+
+// setPVHeating records whether the heating is on because of PV surplus.
+func (hm *HeatingManager) setPVHeating(on bool) {
+	hm.mu.Lock()
+	hm.pvHeating = on
+	hm.mu.Unlock()
+}
+
+// getPVSurplus reads the current PV surplus in watts from the inverter endpoint.
+func (hm *HeatingManager) getPVSurplus(ctx context.Context, pvSurplusURL string) (float64, error) {
+	surplus, err := hm.getNumber(ctx, pvSurplusURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get PV surplus: %w", err)
+	}
+	return surplus, nil
+}
+
+// getNumber reads an endpoint returning a bare number.
+func (hm *HeatingManager) getNumber(ctx context.Context, url string) (float64, error) {
+	resp, err := hm.httpGet(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(string(body)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse response: %w: %v", ErrParse, err)
+	}
+	return number, nil
+}
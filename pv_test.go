@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestControlPVSurplus(t *testing.T) {
+	surplus := "2500"
+	var onCalls, offCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/surplus":
+			_, _ = w.Write([]byte(surplus))
+		case "/on":
+			onCalls++
+		case "/off":
+			offCalls++
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.PVSurplusURL = ts.URL + "/surplus"
+	manager.Config.PVSurplusThresholdWatts = 2000
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+
+	manager.controlPVSurplus(context.Background())
+	manager.controlPVSurplus(context.Background())
+	if onCalls != 1 || !manager.pvHeating {
+		t.Errorf("Expected heating to be turned on once, got %d on calls", onCalls)
+	}
+
+	surplus = "800"
+	manager.controlPVSurplus(context.Background())
+	if offCalls != 1 || manager.pvHeating {
+		t.Errorf("Expected heating to be turned off once, got %d off calls", offCalls)
+	}
+}
+
+func TestControlPVSurplusMinHeatingInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("2500"))
+	}))
+	defer ts.Close()
+
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.PVSurplusURL = ts.URL
+	manager.Config.PVSurplusThresholdWatts = 2000
+	manager.Config.MinHeatingIntervalHours = 6
+	manager.Config.HeatingDurationMinutes = 0
+	controller := &stubController{}
+	manager.HeatingController = controller
+
+	if err := manager.weeklyCheck(context.Background(), controller, false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	manager.heatingWG.Wait()
+
+	clock.Advance(time.Hour)
+	manager.controlPVSurplus(context.Background())
+	if calls := controller.onCalls.Load(); calls != 1 || manager.pvHeating {
+		t.Errorf("Expected PV surplus within the minimum heating interval to leave the heating off, got %d on calls", calls)
+	}
+
+	clock.Advance(6 * time.Hour)
+	manager.controlPVSurplus(context.Background())
+	if calls := controller.onCalls.Load(); calls != 2 || !manager.pvHeating {
+		t.Errorf("Expected PV surplus after the minimum heating interval to turn the heating on, got %d on calls", calls)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// errRelayUnconfirmed is returned when the relay accepted the on command but did not report on.
+var errRelayUnconfirmed = errors.New("relay did not report on")
+
+// Timing of the relay confirmation after switching the heating on.
+var (
+	relayConfirmTimeout = 10 * time.Second
+	relayPollInterval   = time.Second
+)
+
+// relayStatus is the relay state reported by Shelly devices,
+// "output" for Gen2 Switch.GetStatus and "ison" for the Gen1 relay endpoint.
+type relayStatus struct {
+	Output *bool `json:"output"`
+	IsOn   *bool `json:"ison"`
+}
+
+// on reports whether the relay is switched on.
+func (s relayStatus) on() bool {
+	return (s.Output != nil && *s.Output) || (s.IsOn != nil && *s.IsOn)
+}
+
+// shellyRelayURL returns the URL switching the relay with the given ID of the Shelly at baseURL,
+// /relay/<id>?turn=on|off for Gen1 and the Switch.Set RPC for Gen2.
+func shellyRelayURL(baseURL, generation string, id int, on bool) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if generation == shellyGen2 {
+		return fmt.Sprintf("%s/rpc/Switch.Set?id=%d&on=%t", baseURL, id, on)
+	}
+	turn := "off"
+	if on {
+		turn = "on"
+	}
+	return fmt.Sprintf("%s/relay/%d?turn=%s", baseURL, id, turn)
+}
+
+// rpcSwitchResponse is the result of a Gen2 Switch.Set call, or the RPC error it failed with.
+type rpcSwitchResponse struct {
+	WasOn   *bool  `json:"was_on"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// switchRelay calls a relay switch URL and checks the response for the configured generation.
+// Responses without a JSON body, e.g. from a custom on/off URL, only need status 200.
+func (hm *HeatingManager) switchRelay(ctx context.Context, switchURL string, on bool) error {
+	resp, err := hm.shellyGet(ctx, switchURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %v", err)
+	}
+	var rpc rpcSwitchResponse
+	isJSON := json.Unmarshal(body, &rpc) == nil
+	if resp.StatusCode != http.StatusOK {
+		if isJSON && rpc.Message != "" {
+			return fmt.Errorf("%w: RPC error %d: %s", &StatusError{StatusCode: resp.StatusCode}, rpc.Code, rpc.Message)
+		}
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	if !isJSON {
+		return nil
+	}
+
+	if hm.currentConfig().ShellyGeneration == shellyGen2 {
+		if rpc.Message != "" {
+			return fmt.Errorf("RPC error %d: %s", rpc.Code, rpc.Message)
+		}
+		return nil
+	}
+	var status relayStatus
+	if err := json.Unmarshal(body, &status); err == nil && status.IsOn != nil && *status.IsOn != on {
+		return fmt.Errorf("relay reports ison=%t", *status.IsOn)
+	}
+	return nil
+}
+
+// confirmRelayOn polls the relay status until it reports on or relayConfirmTimeout passes.
+// A failed confirmation sends a notification.
+func (hm *HeatingManager) confirmRelayOn(ctx context.Context, shellyStatusURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, relayConfirmTimeout)
+	defer cancel()
+
+	for {
+		on, err := hm.getRelayStatus(ctx, shellyStatusURL)
+		if err == nil && on {
+			return nil
+		}
+		if err != nil {
+			slog.DebugContext(ctx, "Failed to read relay status", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			hm.notify(ctx, eventHeatingUnconfirmed, reasonRelayOff)
+			return fmt.Errorf("failed to turn on Shelly: %w within %v", errRelayUnconfirmed, relayConfirmTimeout)
+		case <-time.After(relayPollInterval):
+		}
+	}
+}
+
+// getRelayStatus reads whether the Shelly relay is switched on.
+func (hm *HeatingManager) getRelayStatus(ctx context.Context, shellyStatusURL string) (bool, error) {
+	resp, err := hm.shellyGet(ctx, shellyStatusURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to get relay status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to get relay status: %w", &StatusError{StatusCode: resp.StatusCode})
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read response body: %v", err)
+	}
+	var status relayStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return false, fmt.Errorf("failed to unmarshal relay status: %w: %v", ErrParse, err)
+	}
+	return status.on(), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSwitchShellyOnConfirmsRelay(t *testing.T) {
+	tests := map[string]struct {
+		status  string
+		wantErr bool
+	}{
+		"relay on":                 {`{"id":0,"output":true}`, false},
+		"command accepted but off": {`{"id":0,"output":false}`, true},
+		"gen1 relay on":            {`{"ison":true}`, false},
+	}
+
+	oldTimeout, oldInterval := relayConfirmTimeout, relayPollInterval
+	relayConfirmTimeout, relayPollInterval = 50*time.Millisecond, 5*time.Millisecond
+	defer func() { relayConfirmTimeout, relayPollInterval = oldTimeout, oldInterval }()
+
+	for name, tt := range tests {
+		var notified []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/status":
+				_, _ = w.Write([]byte(tt.status))
+			case "/notify":
+				notified = append(notified, r.URL.Path)
+			}
+		}))
+
+		manager := newTestManager(t)
+		manager.Config.ShellyStatusURL = ts.URL + "/status"
+		manager.Config.NotifyURL = ts.URL + "/notify"
+
+		err := manager.switchHeatingOn(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", name, tt.wantErr, err)
+		}
+		if tt.wantErr && len(notified) != 1 {
+			t.Errorf("%s: expected a notification, got %d", name, len(notified))
+		}
+		if manager.heatingOn == tt.wantErr {
+			t.Errorf("%s: unexpected heating state %v", name, manager.heatingOn)
+		}
+		ts.Close()
+	}
+}
+
+func TestShellyRelayURL(t *testing.T) {
+	tests := []struct {
+		generation string
+		on         bool
+		want       string
+	}{
+		{shellyGen1, true, "http://shelly/relay/1?turn=on"},
+		{shellyGen1, false, "http://shelly/relay/1?turn=off"},
+		{shellyGen2, true, "http://shelly/rpc/Switch.Set?id=1&on=true"},
+		{shellyGen2, false, "http://shelly/rpc/Switch.Set?id=1&on=false"},
+	}
+	for _, tt := range tests {
+		if got := shellyRelayURL("http://shelly/", tt.generation, 1, tt.on); got != tt.want {
+			t.Errorf("shellyRelayURL(%s, %v) = %s, want %s", tt.generation, tt.on, got, tt.want)
+		}
+	}
+
+	config := Config{ShellyRelayURL: "http://shelly", ShellyGeneration: shellyGen2}
+	config.setDefaults()
+	if config.ShellyHeatingOnURL != "http://shelly/rpc/Switch.Set?id=0&on=true" || config.ShellyHeatingOffURL != "http://shelly/rpc/Switch.Set?id=0&on=false" {
+		t.Errorf("Unexpected derived heating URLs %s and %s", config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)
+	}
+}
+
+func TestSwitchRelayResponse(t *testing.T) {
+	tests := map[string]struct {
+		generation string
+		status     int
+		body       string
+		wantErr    bool
+	}{
+		"gen2 switched":         {shellyGen2, http.StatusOK, `{"was_on":false}`, false},
+		"gen2 rpc error":        {shellyGen2, http.StatusInternalServerError, `{"code":-105,"message":"Argument 'id', value 5 not found!"}`, true},
+		"gen1 relay on":         {shellyGen1, http.StatusOK, `{"ison":true,"has_timer":false}`, false},
+		"gen1 relay stayed off": {shellyGen1, http.StatusOK, `{"ison":false,"has_timer":false}`, true},
+		"custom URL plain text": {shellyGen1, http.StatusOK, `OK`, false},
+	}
+	for name, tt := range tests {
+		manager := newTestManager(t)
+		manager.Config.ShellyGeneration = tt.generation
+		manager.HTTPClient = stubResponse(tt.status, tt.body)
+
+		err := manager.switchRelay(context.Background(), "http://shelly/on", true)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", name, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+)
+
+// StartConfigReload reloads the config file at configPath on every SIGHUP until the context is cancelled.
+// With dryRun set, the reloaded config keeps dry-run mode regardless of the file.
+func (hm *HeatingManager) StartConfigReload(ctx context.Context, configPath string, dryRun bool) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := hm.reloadConfig(configPath, dryRun); err != nil {
+				slog.Error("Rejected reloaded config, keeping the current one", "path", configPath, "err", err)
+			}
+		}
+	}
+}
+
+// reloadConfig loads and validates the config file at configPath and swaps it in.
+// An invalid config is rejected and the current one kept.
+// Ports, HTTP client and log file settings only take effect after a restart.
+func (hm *HeatingManager) reloadConfig(configPath string, dryRun bool) error {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		config.DryRun = true
+	}
+
+	old := hm.currentConfig()
+	if config.MetricsPort != old.MetricsPort || config.HealthPort != old.HealthPort || config.APIPort != old.APIPort || config.HTTPBasePath != old.HTTPBasePath || config.HTTPAuthExcludeHealthz != old.HTTPAuthExcludeHealthz ||
+		config.HTTPTimeout != old.HTTPTimeout || config.InsecureSkipTLSVerify != old.InsecureSkipTLSVerify || config.ShellyCACert != old.ShellyCACert ||
+		config.LogFile != old.LogFile || config.LogMaxSizeMB != old.LogMaxSizeMB || config.LogMaxBackups != old.LogMaxBackups {
+		slog.Warn("Changed ports, HTTP client and log file settings take effect after a restart")
+	}
+
+	if old.ShellyWebSocketURL == "" && config.ShellyWebSocketURL != "" {
+		slog.Warn("Switching from polling to the Shelly WebSocket takes effect after a restart")
+	}
+
+	if !slices.EqualFunc(config.Zones, old.Zones, func(a, b ZoneConfig) bool { return a.Name == b.Name }) {
+		slog.Warn("Added, removed or renamed zones take effect after a restart")
+	}
+
+	hm.applyConfig(config)
+	hm.reloadVacationMode(old, config)
+	hm.applyZoneConfigs(config)
+	slog.SetDefault(newLogger(hm.LogOutput, config.LogLevel, config.location()))
+	slog.Info("Config reloaded", "path", configPath, "threshold", config.TemperatureThreshold, "checkInterval", config.CheckInterval)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTestConfig writes config to a temporary config file and returns its path.
+func writeTestConfig(t *testing.T, config Config) string {
+	t.Helper()
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestReloadConfig(t *testing.T) {
+	manager := newTestManager(t)
+	configChanged := manager.configChangedChan()
+
+	config := manager.currentConfig()
+	config.TemperatureThreshold = config.TemperatureThreshold + 5
+	config.CheckInterval = config.CheckInterval + 1
+	path := writeTestConfig(t, config)
+
+	if err := manager.reloadConfig(path, true); err != nil {
+		t.Fatalf("reloadConfig returned an error: %v", err)
+	}
+	reloaded := manager.currentConfig()
+	if reloaded.TemperatureThreshold != config.TemperatureThreshold {
+		t.Errorf("Expected threshold %v, got %v", config.TemperatureThreshold, reloaded.TemperatureThreshold)
+	}
+	if !reloaded.DryRun {
+		t.Error("Expected the dry-run flag to survive the reload")
+	}
+	if manager.checkInterval() != time.Duration(config.CheckInterval)*time.Minute {
+		t.Errorf("Expected check interval %d minutes, got %v", config.CheckInterval, manager.checkInterval())
+	}
+	select {
+	case <-configChanged:
+	default:
+		t.Error("Expected the config change to be signalled")
+	}
+}
+
+func TestReloadConfigRejectsInvalid(t *testing.T) {
+	manager := newTestManager(t)
+	previous := manager.currentConfig()
+
+	config := previous
+	config.CheckInterval = 0
+	if err := manager.reloadConfig(writeTestConfig(t, config), false); err == nil {
+		t.Fatal("Expected an invalid config to be rejected")
+	}
+	if manager.currentConfig().CheckInterval != previous.CheckInterval {
+		t.Error("Expected the previous config to be kept")
+	}
+}
+
+// TestReloadConfigConcurrent reloads the config while temperature checks run; run with -race.
+func TestReloadConfigConcurrent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("40"))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.ShellyURLs = []string{ts.URL}
+	path := writeTestConfig(t, manager.currentConfig())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = manager.checkTemperature(context.Background(), manager.currentConfig().ShellyURLs)
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if err := manager.reloadConfig(path, false); err != nil {
+			t.Fatalf("reloadConfig returned an error: %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,37 @@
+package main
+
+// ring holds the most recent values up to a fixed capacity, overwriting the oldest one.
+type ring[T any] struct {
+	values []T
+	next   int
+	count  int
+}
+
+// newRing creates a ring holding up to size values.
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{values: make([]T, size)}
+}
+
+// add stores a value, overwriting the oldest one once the ring is full.
+func (r *ring[T]) add(value T) {
+	r.values[r.next] = value
+	r.next = (r.next + 1) % len(r.values)
+	if r.count < len(r.values) {
+		r.count++
+	}
+}
+
+// size returns the capacity of the ring.
+func (r *ring[T]) size() int {
+	return len(r.values)
+}
+
+// list returns the stored values, oldest first.
+func (r *ring[T]) list() []T {
+	list := make([]T, 0, r.count)
+	start := (r.next - r.count + len(r.values)) % len(r.values)
+	for i := 0; i < r.count; i++ {
+		list = append(list, r.values[(start+i)%len(r.values)])
+	}
+	return list
+}
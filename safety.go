@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// enforceSafetyCutoff forces the heating off if the temperature exceeds the maximum safe temperature.
+// It takes priority over any running heating cycle and reports whether the cutoff was triggered.
+func (hm *HeatingManager) enforceSafetyCutoff(ctx context.Context, temperature float64) bool {
+	config := hm.currentConfig()
+	if config.MaxSafeTemperature == 0 || temperature <= config.MaxSafeTemperature {
+		return false
+	}
+
+	slog.ErrorContext(ctx, "Temperature exceeds the maximum safe temperature, forcing heating off", "event", eventSafetyCutoff, "temperature", temperature, "threshold", config.MaxSafeTemperature, "unit", config.TemperatureUnit)
+	hm.cancelHeatingOff()
+	// The heating must go off even while shutting down.
+	if err := hm.switchHeatingOff(detach(ctx), hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)); err != nil {
+		slog.ErrorContext(ctx, "Failed to force heating off", "event", eventSafetyCutoff, "err", err)
+	}
+
+	hm.mu.Lock()
+	hm.pvHeating = false
+	hm.lastSafetyCutoff = hm.Clock.Now()
+	hm.safetyCutoffTemperature = temperature
+	hm.mu.Unlock()
+
+	hm.notify(ctx, eventSafetyCutoff, reasonMaxSafeTemperature)
+	return true
+}
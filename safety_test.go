@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSafetyCutoff(t *testing.T) {
+	var offCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/temp":
+			_, _ = w.Write([]byte(`{"id":100,"tC":92}`))
+		case "/off":
+			offCalls.Add(1)
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.MaxSafeTemperature = 90
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+
+	manager.checkTemperature(context.Background(), []string{ts.URL + "/temp"})
+	if offCalls.Load() != 1 {
+		t.Errorf("Expected heating to be forced off once, got %d off calls", offCalls.Load())
+	}
+
+	state, err := manager.loadState()
+	if err != nil {
+		t.Fatalf("loadState returned an error: %v", err)
+	}
+	if state.LastSafetyCutoff.IsZero() || state.SafetyCutoffTemperature != 92 {
+		t.Errorf("Expected safety cutoff to be recorded in state, got %+v", state)
+	}
+}
+
+func TestSafetyCutoffDisabled(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.MaxSafeTemperature = 0
+	if manager.enforceSafetyCutoff(context.Background(), 120) {
+		t.Error("Expected no safety cutoff when maxSafeTemperature is unset")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// shutdownTimeout bounds how long an HTTP server may take to finish in-flight requests on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// serveHTTP serves handler on addr until the context is cancelled.
+// With a base path, the handler is served below it only.
+func serveHTTP(ctx context.Context, addr, basePath string, handler http.Handler) {
+	server := &http.Server{Addr: addr, Handler: withBasePath(basePath, handler)}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Warn("Failed to shut down HTTP server", "addr", addr, "err", err)
+		}
+	}()
+
+	slog.Info("HTTP server listening", "addr", addr, "basePath", basePath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("HTTP server failed", "addr", addr, "err", err)
+	}
+}
+
+// withBasePath serves handler below basePath, stripping the prefix so handlers keep their root-based routes.
+// A request for the base path without a trailing slash is redirected to it. An empty base path serves handler as is.
+func withBasePath(basePath string, handler http.Handler) http.Handler {
+	if basePath == "" {
+		return handler
+	}
+	mux := http.NewServeMux()
+	mux.Handle(basePath+"/", http.StripPrefix(basePath, handler))
+	return mux
+}
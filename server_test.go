@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithBasePath(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.HTTPBasePath = "/pvheat"
+	handler := withBasePath(manager.Config.HTTPBasePath, manager.apiHandler())
+
+	for path, want := range map[string]int{
+		"/pvheat/status": http.StatusOK,
+		"/pvheat/":       http.StatusOK,
+		"/status":        http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pvheat", nil))
+	if location := rec.Header().Get("Location"); location != "/pvheat/" {
+		t.Errorf("Expected a redirect to /pvheat/, got %d to %q", rec.Code, location)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pvheat/", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `url=/pvheat/"`) || !strings.Contains(body, `href="/pvheat/status"`) {
+		t.Errorf("Expected the dashboard refresh and links below the base path, got %q", body)
+	}
+}
+
+func TestWithoutBasePath(t *testing.T) {
+	manager := newTestManager(t)
+	rec := httptest.NewRecorder()
+	withBasePath("", manager.apiHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 at the root without a base path, got %d", rec.Code)
+	}
+}
+
+func TestSetDefaultsTrimsBasePath(t *testing.T) {
+	config := testConfig()
+	config.HTTPBasePath = "/pvheat/"
+	config.setDefaults()
+	if config.HTTPBasePath != "/pvheat" {
+		t.Errorf("Expected the trailing slash to be trimmed, got %q", config.HTTPBasePath)
+	}
+}
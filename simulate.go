@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// simulationReading is a timestamped temperature replayed by -simulate.
+type simulationReading struct {
+	Time        time.Time
+	Temperature float64
+}
+
+// loadSimulation reads a CSV file with a header row and rows of an RFC3339 timestamp and a temperature
+// in the configured unit; further columns are ignored, so a Celsius history file can be replayed as is.
+// The timestamps must not go backwards.
+func loadSimulation(path string) ([]simulationReading, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open simulation file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read simulation header: %w", err)
+	}
+	var readings []simulationReading
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read simulation file: %w", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("simulation line %d: expected a timestamp and a temperature", line)
+		}
+		readTime, err := time.Parse(time.RFC3339, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("simulation line %d: invalid timestamp: %w", line, err)
+		}
+		temperature, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("simulation line %d: invalid temperature: %w", line, err)
+		}
+		if len(readings) > 0 && readTime.Before(readings[len(readings)-1].Time) {
+			return nil, fmt.Errorf("simulation line %d: timestamp %s is before the previous one", line, record[0])
+		}
+		readings = append(readings, simulationReading{Time: readTime, Temperature: temperature})
+	}
+	if len(readings) == 0 {
+		return nil, fmt.Errorf("simulation file %s has no readings", path)
+	}
+	return readings, nil
+}
+
+// simulationClock is the Clock of a simulation, set to the timestamp of the reading being replayed.
+type simulationClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Now returns the timestamp of the current reading.
+func (c *simulationClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// set moves the clock to t.
+func (c *simulationClock) set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// simulationSource is the TemperatureSource of a simulation, returning the reading being replayed.
+type simulationSource struct {
+	mu          sync.Mutex
+	temperature float64
+}
+
+// Read returns the current simulated temperature.
+func (s *simulationSource) Read(ctx context.Context) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.temperature, nil
+}
+
+// set replaces the simulated temperature.
+func (s *simulationSource) set(temperature float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.temperature = temperature
+}
+
+// String names the source in logs.
+func (s *simulationSource) String() string {
+	return "simulation"
+}
+
+// simulationConfig returns config in dry-run mode with all outbound integrations disabled,
+// so a simulation never switches the heating, sends notifications or writes readings anywhere.
+func simulationConfig(config Config) Config {
+	config.DryRun = true
+	config.PVSurplusURL = ""
+	config.BatterySOCURL = ""
+	config.ShellyPowerURL = ""
+	config.NotifyURL = ""
+	config.TelegramBotToken = ""
+	config.SlackWebhookURL = ""
+	config.SMTPHost = ""
+	config.MQTTBroker = ""
+	config.InfluxURL = ""
+	config.HistoryFile = ""
+	config.OnHeatingCommand = ""
+	config.OnHeatingFailCommand = ""
+	return config
+}
+
+// runSimulation replays the readings of a CSV file through checkTemperature and the weekly check,
+// with the clock following the timestamps. Each gap between readings is waited for divided by speed;
+// a speed of 0 replays as fast as possible. State is kept in a temporary directory starting empty,
+// so the real state file is left untouched. Since the simulated clock does not drive the heating timers,
+// a heating cycle started by the weekly check ends right away; the CSV carries the resulting temperatures.
+func (hm *HeatingManager) runSimulation(ctx context.Context, path string, speed float64) error {
+	readings, err := loadSimulation(path)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "pv-heating-simulation-")
+	if err != nil {
+		return fmt.Errorf("failed to create simulation state directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	clock := &simulationClock{now: readings[0].Time}
+	source := &simulationSource{}
+	hm.Clock = clock
+	hm.TemperatureSources = []TemperatureSource{source}
+	hm.StateFile = filepath.Join(dir, "state.json")
+	hm.LastCheckFile = filepath.Join(dir, "lastCheck.txt")
+	hm.applyConfig(simulationConfig(hm.currentConfig()))
+	hm.mu.Lock()
+	hm.startTime = clock.Now()
+	hm.mu.Unlock()
+	hm.restoreState()
+
+	config := hm.currentConfig()
+	controller := hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)
+	weeklyChecks := 0
+	slog.Info("Starting simulation", "file", path, "readings", len(readings), "from", readings[0].Time, "to", readings[len(readings)-1].Time, "speed", speed)
+	for i, reading := range readings {
+		if speed > 0 && i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(float64(reading.Time.Sub(readings[i-1].Time)) / speed)):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		clock.set(reading.Time)
+		source.set(reading.Temperature)
+		if err := hm.checkTemperature(ctx, config.ShellyURLs); err != nil {
+			slog.Warn("Simulated temperature check failed", "time", reading.Time, "err", err)
+		}
+		if hm.nextWeeklyCheckDuration() > 0 {
+			continue
+		}
+		weeklyChecks++
+		if err := hm.weeklyCheck(ctx, controller, false); err != nil {
+			slog.Warn("Simulated weekly check failed", "time", reading.Time, "err", err)
+		}
+		hm.cancelHeatingOff()
+		hm.heatingWG.Wait()
+	}
+
+	slog.Info("Simulation finished", "readings", len(readings), "weeklyChecks", weeklyChecks, "temperatureExceeded", hm.isTemperatureExceeded(), "nextWeeklyCheck", formatNextWeeklyCheck(hm.NextWeeklyCheck()))
+	return nil
+}
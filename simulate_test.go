@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeSimulation writes a simulation CSV file and returns its path.
+func writeSimulation(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "simulation.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write simulation file: %v", err)
+	}
+	return path
+}
+
+func TestLoadSimulation(t *testing.T) {
+	path := writeSimulation(t, "timestamp,temperature_celsius,threshold_exceeded\n"+
+		"2026-01-05T10:00:00Z,50.5,false\n"+
+		"2026-01-05T10:05:00Z,56,true\n")
+	readings, err := loadSimulation(path)
+	if err != nil {
+		t.Fatalf("loadSimulation returned an error: %v", err)
+	}
+	if len(readings) != 2 || readings[0].Temperature != 50.5 || !readings[1].Time.Equal(time.Date(2026, 1, 5, 10, 5, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected readings %v", readings)
+	}
+}
+
+func TestLoadSimulationInvalid(t *testing.T) {
+	tests := map[string]string{
+		"no readings":         "timestamp,temperature\n",
+		"invalid timestamp":   "timestamp,temperature\nyesterday,50\n",
+		"invalid temperature": "timestamp,temperature\n2026-01-05T10:00:00Z,warm\n",
+		"missing temperature": "timestamp,temperature\n2026-01-05T10:00:00Z\n",
+		"backwards":           "timestamp,temperature\n2026-01-05T10:00:00Z,50\n2026-01-05T09:00:00Z,51\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadSimulation(writeSimulation(t, content)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRunSimulation(t *testing.T) {
+	manager := newTestManager(t)
+	stateFile := manager.StateFile
+	controller := &stubController{}
+	manager.HeatingController = controller
+	path := writeSimulation(t, "timestamp,temperature\n"+
+		"2026-01-05T10:00:00Z,50\n"+
+		"2026-01-05T10:05:00Z,56\n"+
+		"2026-01-12T11:00:00Z,48\n"+
+		"2026-01-19T12:00:00Z,49\n")
+
+	if err := manager.runSimulation(context.Background(), path, 0); err != nil {
+		t.Fatalf("runSimulation returned an error: %v", err)
+	}
+	if controller.onCalls.Load() != 0 {
+		t.Error("Expected the simulation not to switch the heating")
+	}
+	if manager.LastTemperature != 49 || !manager.LastReadTime.Equal(time.Date(2026, 1, 19, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the last reading 49 at the last timestamp, got %v at %v", manager.LastTemperature, manager.LastReadTime)
+	}
+	var results []string
+	for _, outcome := range manager.weeklyOutcomes {
+		results = append(results, outcome.Result)
+	}
+	if want := []string{outcomeHeated, outcomeSkipped, outcomeHeated}; !slices.Equal(results, want) {
+		t.Errorf("Expected weekly outcomes %v, got %v", want, results)
+	}
+	if want := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC); !manager.NextWeeklyCheck().Equal(want) {
+		t.Errorf("Expected the next weekly check at %v, got %v", want, manager.NextWeeklyCheck())
+	}
+	if _, err := os.Stat(stateFile); !os.IsNotExist(err) {
+		t.Error("Expected the simulation to leave the state file untouched")
+	}
+}
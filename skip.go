@@ -0,0 +1,23 @@
+package main
+
+import "log/slog"
+
+// SetSkipNextWeekly sets or clears the manual override skipping the next weekly heating and persists it.
+func (hm *HeatingManager) SetSkipNextWeekly(skip bool) error {
+	hm.mu.Lock()
+	hm.skipNextWeekly = skip
+	hm.mu.Unlock()
+	if skip {
+		slog.Info("Next weekly heating will be skipped on request")
+	} else {
+		slog.Info("Skipping the next weekly heating cancelled")
+	}
+	return hm.saveState()
+}
+
+// isSkipNextWeekly reports whether the next weekly heating is skipped by manual override.
+func (hm *HeatingManager) isSkipNextWeekly() bool {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return hm.skipNextWeekly
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWeeklyCheckSkipNext(t *testing.T) {
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/on" {
+			onCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	defer manager.cancelHeatingOff()
+	if err := manager.SetSkipNextWeekly(true); err != nil {
+		t.Fatalf("SetSkipNextWeekly returned an error: %v", err)
+	}
+
+	// The override survives a restart.
+	restarted := newTestManager(t)
+	restarted.StateFile = manager.StateFile
+	restarted.restoreState()
+	if !restarted.isSkipNextWeekly() {
+		t.Fatal("Expected the skip override to be restored")
+	}
+
+	if err := restarted.weeklyCheck(context.Background(), restarted.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if onCalls.Load() != 0 {
+		t.Errorf("Expected the heating to be skipped, got %d on calls", onCalls.Load())
+	}
+	if restarted.isSkipNextWeekly() {
+		t.Error("Expected the skip override to be cleared after the weekly check")
+	}
+
+	defer restarted.cancelHeatingOff()
+	if err := restarted.weeklyCheck(context.Background(), restarted.heatingController(ts.URL+"/on", ts.URL+"/off"), false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected the following weekly check to heat, got %d on calls", onCalls.Load())
+	}
+}
+
+func TestWeeklyCheckForceIgnoresSkipNext(t *testing.T) {
+	var onCalls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/on" {
+			onCalls.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	defer manager.cancelHeatingOff()
+	if err := manager.SetSkipNextWeekly(true); err != nil {
+		t.Fatalf("SetSkipNextWeekly returned an error: %v", err)
+	}
+	if err := manager.weeklyCheck(context.Background(), manager.heatingController(ts.URL+"/on", ts.URL+"/off"), true); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if onCalls.Load() != 1 {
+		t.Errorf("Expected a forced run to heat, got %d on calls", onCalls.Load())
+	}
+	if !manager.isSkipNextWeekly() {
+		t.Error("Expected the skip override to stay pending after a forced run")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// slackEvents are the notification events that are also sent to Slack.
+var slackEvents = map[string]bool{
+	eventHeatingOn:        true,
+	eventHeatingFailed:    true,
+	eventHeatingOffFailed: true,
+	eventReadFailures:     true,
+	eventServiceStarted:   true,
+	eventServiceStopped:   true,
+}
+
+// slackText is a text object of a Slack block.
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a section block of a Slack message.
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+// slackMessage is the request body of a Slack incoming webhook.
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// newSlackMessage formats a notification with the current temperature and the next weekly run.
+func (hm *HeatingManager) newSlackMessage(notification Notification) slackMessage {
+	config := hm.currentConfig()
+	temperature := "no reading yet"
+	if value, readTime := hm.CurrentTemperature(); !readTime.IsZero() {
+		temperature = config.formatTemperature(value) + " °" + config.TemperatureUnit
+	}
+
+	return slackMessage{
+		Text: notification.Message(),
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + notification.Message() + "*"}},
+			{Type: "section", Fields: []slackText{
+				{Type: "mrkdwn", Text: "*Temperature*\n" + temperature},
+				{Type: "mrkdwn", Text: "*Next weekly run*\n" + formatNextWeeklyCheck(hm.NextWeeklyCheck())},
+			}},
+		},
+	}
+}
+
+// sendSlack posts a notification to the configured Slack incoming webhook.
+func (hm *HeatingManager) sendSlack(notification Notification) error {
+	body, err := json.Marshal(hm.newSlackMessage(notification))
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %v", err)
+	}
+
+	resp, err := hm.httpPost(hm.currentConfig().SlackWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send Slack message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send Slack message: status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendSlack(t *testing.T) {
+	var received slackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode Slack message: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.SlackWebhookURL = ts.URL
+	manager.LastTemperature = 58.3
+	manager.LastReadTime = time.Now()
+
+	manager.notify(context.Background(), eventHeatingOn, reasonWeeklyLegionella)
+	if received.Text == "" || len(received.Blocks) != 2 {
+		t.Fatalf("Unexpected Slack message: %+v", received)
+	}
+	fields := received.Blocks[1].Fields
+	if len(fields) != 2 || !strings.Contains(fields[0].Text, "58.3 °C") || !strings.Contains(fields[1].Text, "pending") {
+		t.Errorf("Expected temperature and next run fields, got %+v", fields)
+	}
+}
+
+func TestSendSlackFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.SlackWebhookURL = ts.URL
+	if err := manager.sendSlack(Notification{Event: eventReadFailures}); err == nil {
+		t.Error("Expected an error for a failing webhook")
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+// average returns the mean of the readings, or 0 if there are none.
+func average(readings []float64) float64 {
+	if len(readings) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, reading := range readings {
+		sum += reading
+	}
+	return sum / float64(len(readings))
+}
+
+// smoothTemperature adds a reading to the smoothing window and returns the moving average.
+// A changed window size starts a new window.
+func (hm *HeatingManager) smoothTemperature(temperature float64) float64 {
+	window := hm.currentConfig().SmoothingWindow
+	if window <= 1 {
+		return temperature
+	}
+	if hm.readings == nil || hm.readings.size() != window {
+		hm.readings = newRing[float64](window)
+	}
+	hm.readings.add(temperature)
+	return average(hm.readings.list())
+}
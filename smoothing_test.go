@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRingAverage(t *testing.T) {
+	buffer := newRing[float64](3)
+	if avg := average(buffer.list()); avg != 0 {
+		t.Errorf("Expected 0 for an empty buffer, got %v", avg)
+	}
+	buffer.add(10)
+	buffer.add(20)
+	if avg := average(buffer.list()); avg != 15 {
+		t.Errorf("Expected 15 with two readings, got %v", avg)
+	}
+	buffer.add(30)
+	buffer.add(40)
+	if got := buffer.list(); len(got) != 3 || got[0] != 20 || got[2] != 40 {
+		t.Errorf("Expected the readings 20, 30 and 40 oldest first, got %v", got)
+	}
+	if avg := average(buffer.list()); avg != 30 {
+		t.Errorf("Expected the oldest reading to be dropped, got average %v", avg)
+	}
+}
+
+func TestCheckTemperatureIgnoresSpike(t *testing.T) {
+	var body atomic.Value
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(body.Load().(string)))
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.TemperatureThreshold = 55
+	manager.Config.SmoothingWindow = 3
+
+	for _, reading := range []string{"50", "50", "62"} {
+		body.Store(`{"id":100,"tC":` + reading + `}`)
+		manager.checkTemperature(context.Background(), []string{ts.URL})
+	}
+	if manager.TemperatureExceeded {
+		t.Error("Expected a single spike not to trip the threshold")
+	}
+
+	body.Store(`{"id":100,"tC":62}`)
+	manager.checkTemperature(context.Background(), []string{ts.URL})
+	if !manager.TemperatureExceeded {
+		t.Error("Expected a sustained rise to trip the threshold")
+	}
+}
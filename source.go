@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// TemperatureSource reads a temperature in the configured unit.
+// The Shelly sensors in ShellyURLs are the default; other backends can be set in HeatingManager.TemperatureSources.
+type TemperatureSource interface {
+	Read(ctx context.Context) (float64, error)
+}
+
+// shellySource is the TemperatureSource of a Shelly temperature sensor.
+type shellySource struct {
+	hm  *HeatingManager
+	url string
+}
+
+// Read gets the temperature of the Shelly device, retrying failed requests.
+func (s shellySource) Read(ctx context.Context) (float64, error) {
+	return s.hm.getTemperature(ctx, s.url)
+}
+
+// String returns the URL of the Shelly device.
+func (s shellySource) String() string {
+	return s.url
+}
+
+// shellySources returns a TemperatureSource for each Shelly sensor URL.
+func (hm *HeatingManager) shellySources(shellyURLs []string) []TemperatureSource {
+	sources := make([]TemperatureSource, 0, len(shellyURLs))
+	for _, url := range shellyURLs {
+		sources = append(sources, shellySource{hm: hm, url: url})
+	}
+	return sources
+}
+
+// sourceName describes a temperature source for logging, using its String method if it has one.
+func sourceName(source TemperatureSource) string {
+	if stringer, ok := source.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", source)
+}
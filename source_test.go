@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubSource is a TemperatureSource returning a fixed reading.
+type stubSource struct {
+	temperature float64
+	err         error
+}
+
+func (s stubSource) Read(ctx context.Context) (float64, error) {
+	return s.temperature, s.err
+}
+
+func TestCheckTemperatureCustomSources(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.TemperatureThreshold = 55
+	manager.TemperatureSources = []TemperatureSource{
+		stubSource{temperature: 48},
+		stubSource{err: errors.New("sensor offline")},
+		stubSource{temperature: 57},
+	}
+
+	if err := manager.checkTemperature(context.Background(), manager.Config.ShellyURLs); err != nil {
+		t.Fatalf("checkTemperature returned an error: %v", err)
+	}
+	if manager.LastTemperature != 57 {
+		t.Errorf("Expected the hottest reading 57, got %v", manager.LastTemperature)
+	}
+	if !manager.TemperatureExceeded {
+		t.Error("TemperatureExceeded should be true for temperature 57 above the threshold of 55")
+	}
+}
+
+func TestReadTemperatureCustomSourcesFail(t *testing.T) {
+	manager := newTestManager(t)
+	manager.TemperatureSources = []TemperatureSource{stubSource{err: errors.New("sensor offline")}}
+
+	if _, _, err := manager.readTemperature(context.Background(), manager.Config.ShellyURLs); err == nil {
+		t.Error("Expected an error when all sources fail")
+	}
+}
+
+func TestSourceName(t *testing.T) {
+	if got := sourceName(shellySource{url: "http://shelly/temp"}); got != "http://shelly/temp" {
+		t.Errorf("Expected the Shelly URL, got %q", got)
+	}
+	if got := sourceName(stubSource{}); got != "main.stubSource" {
+		t.Errorf("Expected the type name, got %q", got)
+	}
+}
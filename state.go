@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// State is the manager state persisted across restarts.
+type State struct {
+	LastCheck           time.Time `json:"lastCheck"`           // Time of the last weekly check.
+	LastHeatingRun      time.Time `json:"lastHeatingRun"`      // Time the heating was last turned on.
+	HeatingFailedAt     time.Time `json:"heatingFailedAt"`     // Time the weekly heating first failed since the last completed weekly check.
+	HeatingOffAt        time.Time `json:"heatingOffAt"`        // Scheduled end of the running heating cycle, zero if none.
+	TemperatureExceeded bool      `json:"temperatureExceeded"` // Indicates if the temperature threshold has been exceeded.
+	LastExceeded        time.Time `json:"lastExceeded"`        // Time the threshold was last exceeded.
+	LastTemperature     float64   `json:"lastTemperature"`     // Last successfully read temperature.
+
+	LastSafetyCutoff        time.Time `json:"lastSafetyCutoff"`        // Time the safety cutoff was last triggered.
+	SafetyCutoffTemperature float64   `json:"safetyCutoffTemperature"` // Temperature that triggered the last safety cutoff.
+
+	PasteurizedSeconds float64 `json:"pasteurizedSeconds"` // Seconds at or above the pasteurization temperature since the last weekly check.
+	SkipNextWeekly     bool    `json:"skipNextWeekly"`     // Skip the next weekly heating by manual override.
+	VacationMode       *bool   `json:"vacationMode"`       // Vacation mode set via the API, nil if it follows the config.
+
+	WeeklyOutcomes []WeeklyOutcome `json:"weeklyOutcomes"` // Recent weekly check outcomes, oldest first.
+
+	SuccessfulReads    int64 `json:"successfulReads"`    // Lifetime total of successful temperature reads.
+	FailedReads        int64 `json:"failedReads"`        // Lifetime total of failed temperature reads.
+	HeatingActivations int64 `json:"heatingActivations"` // Lifetime total of heating activations.
+}
+
+// loadState reads the persisted state. A missing state file yields an empty state.
+func (hm *HeatingManager) loadState() (State, error) {
+	var state State
+	data, err := os.ReadFile(hm.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	return state, nil
+}
+
+// saveState persists the current state.
+func (hm *HeatingManager) saveState() error {
+	hm.mu.Lock()
+	state := State{
+		LastCheck:           hm.lastCheck,
+		LastHeatingRun:      hm.lastHeatingRun,
+		HeatingFailedAt:     hm.heatingFailedAt,
+		HeatingOffAt:        hm.heatingOffAt,
+		TemperatureExceeded: hm.TemperatureExceeded,
+		LastExceeded:        hm.lastExceeded,
+		LastTemperature:     hm.LastTemperature,
+
+		LastSafetyCutoff:        hm.lastSafetyCutoff,
+		SafetyCutoffTemperature: hm.safetyCutoffTemperature,
+
+		PasteurizedSeconds: hm.pasteurizedDuration.Seconds(),
+		SkipNextWeekly:     hm.skipNextWeekly,
+		VacationMode:       hm.vacationOverride,
+
+		WeeklyOutcomes: slices.Clone(hm.weeklyOutcomes),
+
+		SuccessfulReads:    hm.successfulReads,
+		FailedReads:        hm.failedReads,
+		HeatingActivations: hm.heatingActivations,
+	}
+	hm.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	hm.stateMu.Lock()
+	defer hm.stateMu.Unlock()
+	if err := writeFileAtomic(hm.StateFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so a crash mid-write leaves either the old or the new file behind, never a truncated one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// restoreState restores the persisted state, migrating a legacy last check file if present.
+// A threshold flag that was last set longer than the weekly check interval ago is stale and ignored.
+// An unreadable or corrupt state file is logged and treated as if no check had run yet.
+func (hm *HeatingManager) restoreState() {
+	config := hm.currentConfig()
+	state, err := hm.loadState()
+	if err != nil {
+		slog.Warn("Failed to load state, treating as never checked", "file", hm.StateFile, "err", err)
+		state = State{}
+	}
+
+	migrated := false
+	if state.LastCheck.IsZero() {
+		if lastCheck, err := hm.readLegacyLastCheck(); err == nil {
+			state.LastCheck = lastCheck
+			migrated = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("Failed to migrate legacy last check file", "file", hm.LastCheckFile, "err", err)
+		}
+	}
+
+	if state.TemperatureExceeded {
+		weeklyInterval := time.Duration(config.WeeklyCheckInterval) * time.Hour
+		if hm.Clock.Now().Sub(state.LastExceeded) > weeklyInterval {
+			slog.Info("Ignoring stale temperature exceeded flag", "lastExceeded", state.LastExceeded)
+			state.TemperatureExceeded = false
+		} else {
+			slog.Info("Restored temperature exceeded flag", "lastExceeded", state.LastExceeded)
+		}
+	}
+
+	hm.mu.Lock()
+	hm.lastCheck = state.LastCheck
+	hm.lastHeatingRun = state.LastHeatingRun
+	hm.heatingFailedAt = state.HeatingFailedAt
+	hm.heatingOffAt = state.HeatingOffAt
+	hm.TemperatureExceeded = state.TemperatureExceeded
+	hm.lastExceeded = state.LastExceeded
+	hm.LastTemperature = state.LastTemperature
+	hm.lastSafetyCutoff = state.LastSafetyCutoff
+	hm.safetyCutoffTemperature = state.SafetyCutoffTemperature
+	hm.pasteurizedDuration = time.Duration(state.PasteurizedSeconds * float64(time.Second))
+	hm.skipNextWeekly = state.SkipNextWeekly
+	hm.vacationOverride = state.VacationMode
+	hm.weeklyOutcomes = state.WeeklyOutcomes
+	hm.successfulReads = state.SuccessfulReads
+	hm.failedReads = state.FailedReads
+	hm.heatingActivations = state.HeatingActivations
+	hm.mu.Unlock()
+	thresholdExceededGauge.WithLabelValues(hm.zoneName()).Set(boolToFloat(state.TemperatureExceeded))
+
+	if migrated {
+		if err := hm.saveState(); err != nil {
+			slog.Warn("Failed to save migrated state", "err", err)
+			return
+		}
+		if err := os.Remove(hm.LastCheckFile); err != nil {
+			slog.Warn("Failed to remove legacy last check file", "file", hm.LastCheckFile, "err", err)
+		}
+		slog.Info("Migrated legacy last check file into state file", "file", hm.LastCheckFile, "stateFile", hm.StateFile)
+	}
+}
+
+// readLegacyLastCheck reads the last check time from the legacy RFC3339 text file.
+func (hm *HeatingManager) readLegacyLastCheck() (time.Time, error) {
+	data, err := os.ReadFile(hm.LastCheckFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	lastCheck, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse last check time: %w", err)
+	}
+	return lastCheck, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestoreState(t *testing.T) {
+	manager := newTestManager(t)
+	manager.setTemperatureExceeded(true)
+	manager.saveLastCheckTime(context.Background())
+
+	restored := newTestManager(t)
+	restored.StateFile = manager.StateFile
+	restored.restoreState()
+	if !restored.TemperatureExceeded {
+		t.Error("Expected TemperatureExceeded to be restored")
+	}
+	if _, err := restored.readLastCheckTime(); err != nil {
+		t.Errorf("Expected last check time to be restored: %v", err)
+	}
+}
+
+func TestRestoreStateIgnoresStaleFlag(t *testing.T) {
+	manager := newTestManager(t)
+	stale := time.Now().Add(-time.Duration(manager.Config.WeeklyCheckInterval+1) * time.Hour)
+	data := []byte(`{"temperatureExceeded":true,"lastExceeded":"` + stale.Format(time.RFC3339) + `"}`)
+	if err := os.WriteFile(manager.StateFile, data, 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	manager.restoreState()
+	if manager.TemperatureExceeded {
+		t.Error("Expected a stale TemperatureExceeded flag to be ignored")
+	}
+}
+
+func TestRestoreStateMigratesLegacyLastCheck(t *testing.T) {
+	manager := newTestManager(t)
+	lastCheck := time.Date(2024, 3, 3, 3, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(manager.LastCheckFile, []byte(lastCheck.Format(time.RFC3339)), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	manager.restoreState()
+	if got, err := manager.readLastCheckTime(); err != nil || !got.Equal(lastCheck) {
+		t.Errorf("Expected migrated last check %v, got %v (%v)", lastCheck, got, err)
+	}
+	if _, err := os.Stat(manager.LastCheckFile); !os.IsNotExist(err) {
+		t.Error("Expected legacy last check file to be removed")
+	}
+
+	state, err := manager.loadState()
+	if err != nil {
+		t.Fatalf("loadState returned an error: %v", err)
+	}
+	if !state.LastCheck.Equal(lastCheck) {
+		t.Errorf("Expected last check %v in state file, got %v", lastCheck, state.LastCheck)
+	}
+}
+
+func TestSaveStateIsAtomic(t *testing.T) {
+	manager := newTestManager(t)
+	manager.saveLastCheckTime(context.Background())
+
+	entries, err := os.ReadDir(filepath.Dir(manager.StateFile))
+	if err != nil {
+		t.Fatalf("Failed to list state directory: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(manager.StateFile) {
+			t.Errorf("Unexpected leftover file %s", entry.Name())
+		}
+	}
+}
+
+func TestRestoreStateCorruptFile(t *testing.T) {
+	manager := newTestManager(t)
+	if err := os.WriteFile(manager.StateFile, []byte(`{"lastCheck":"2024-03-`), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+	lastCheck := time.Date(2024, 3, 3, 3, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(manager.LastCheckFile, []byte(lastCheck.Format(time.RFC3339)), 0644); err != nil {
+		t.Fatalf("Failed to write legacy file: %v", err)
+	}
+
+	manager.restoreState()
+	if got, err := manager.readLastCheckTime(); err != nil || !got.Equal(lastCheck) {
+		t.Errorf("Expected the legacy last check %v despite the corrupt state file, got %v (%v)", lastCheck, got, err)
+	}
+	if _, err := manager.loadState(); err != nil {
+		t.Errorf("Expected the corrupt state file to be replaced, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "time"
+
+// Stats are operational statistics of the manager.
+// The counters are lifetime totals, persisted in the state file across restarts.
+type Stats struct {
+	StartTime          time.Time `json:"startTime"`          // Time the manager was started.
+	UptimeSeconds      int64     `json:"uptimeSeconds"`      // Seconds since the manager was started.
+	SuccessfulReads    int64     `json:"successfulReads"`    // Total successful temperature reads.
+	FailedReads        int64     `json:"failedReads"`        // Total failed temperature reads.
+	HeatingActivations int64     `json:"heatingActivations"` // Total times the heating was turned on.
+}
+
+// Stats returns the current operational statistics.
+func (hm *HeatingManager) Stats() Stats {
+	now := hm.Clock.Now()
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	return Stats{
+		StartTime:          hm.startTime,
+		UptimeSeconds:      int64(now.Sub(hm.startTime).Seconds()),
+		SuccessfulReads:    hm.successfulReads,
+		FailedReads:        hm.failedReads,
+		HeatingActivations: hm.heatingActivations,
+	}
+}
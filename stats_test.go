@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatsPersistAcrossRestarts(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.startTime = clock.now
+	manager.Config.ShellyURLs = []string{"http://shelly/temp"}
+	manager.Config.RetryBackoff = 1
+
+	manager.HTTPClient = stubResponse(http.StatusServiceUnavailable, "")
+	_ = manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	manager.HTTPClient = stubResponse(http.StatusOK, `{"id":0,"tC":45}`)
+	_ = manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	_ = manager.checkTemperature(context.Background(), manager.Config.ShellyURLs)
+	if err := manager.switchHeatingOn(context.Background(), manager.heatingController("http://shelly/on", "http://shelly/off")); err != nil {
+		t.Fatalf("switchHeatingOn returned an error: %v", err)
+	}
+	clock.Advance(90 * time.Second)
+
+	want := Stats{StartTime: clock.now.Add(-90 * time.Second), UptimeSeconds: 90, SuccessfulReads: 2, FailedReads: 1, HeatingActivations: 1}
+	if stats := manager.Stats(); stats != want {
+		t.Errorf("Expected stats %+v, got %+v", want, stats)
+	}
+
+	restored := newTestManager(t)
+	restored.StateFile = manager.StateFile
+	restored.restoreState()
+	stats := restored.Stats()
+	if stats.SuccessfulReads != 2 || stats.FailedReads != 1 || stats.HeatingActivations != 1 {
+		t.Errorf("Expected the counters to be restored, got %+v", stats)
+	}
+}
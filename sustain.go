@@ -0,0 +1,23 @@
+package main
+
+import "time"
+
+// sustainAboveThreshold tracks how long the smoothed temperature has been above the threshold.
+// It returns the time it crossed above and whether it has stayed above for ThresholdSustainMinutes,
+// so a brief spike, e.g. from sunlight on a sensor, does not count as exceeded. A reading at or
+// below the threshold resets the window.
+func (hm *HeatingManager) sustainAboveThreshold(above bool) (time.Time, bool) {
+	sustain := time.Duration(hm.currentConfig().ThresholdSustainMinutes) * time.Minute
+	now := hm.Clock.Now()
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	if !above {
+		hm.crossedAboveAt = time.Time{}
+		return time.Time{}, false
+	}
+	if hm.crossedAboveAt.IsZero() {
+		hm.crossedAboveAt = now
+	}
+	return hm.crossedAboveAt, now.Sub(hm.crossedAboveAt) >= sustain
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckTemperatureThresholdSustain(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+	manager := newTestManager(t)
+	manager.Clock = clock
+	manager.Config.TemperatureThreshold = 55
+	manager.Config.ThresholdSustainMinutes = 10
+
+	steps := []struct {
+		advance     time.Duration
+		temperature float64
+		exceeded    bool
+	}{
+		{0, 57, false},               // crossed above, window starts
+		{5 * time.Minute, 57, false}, // spike not sustained yet
+		{time.Minute, 54, false},     // dropped below, window resets
+		{time.Minute, 57, false},     // crossed above again
+		{9 * time.Minute, 57, false}, // one minute short
+		{time.Minute, 57, true},      // sustained for 10 minutes
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		manager.TemperatureSources = []TemperatureSource{stubSource{temperature: step.temperature}}
+		if err := manager.checkTemperature(context.Background(), nil); err != nil {
+			t.Fatalf("Step %d: checkTemperature returned an error: %v", i, err)
+		}
+		if manager.isTemperatureExceeded() != step.exceeded {
+			t.Errorf("Step %d at %v°C: expected TemperatureExceeded=%v, got %v", i, step.temperature, step.exceeded, manager.isTemperatureExceeded())
+		}
+	}
+}
+
+func TestSustainAboveThresholdWithoutWindow(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Clock = &fakeClock{now: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)}
+
+	if _, sustained := manager.sustainAboveThreshold(true); !sustained {
+		t.Error("Expected a single reading above the threshold to count without a sustain window")
+	}
+	if crossedAt, sustained := manager.sustainAboveThreshold(false); sustained || !crossedAt.IsZero() {
+		t.Errorf("Expected a reading below the threshold to reset the window, got %v, %v", crossedAt, sustained)
+	}
+}
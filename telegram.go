@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// telegramAPIURL is the base URL of the Telegram Bot API.
+var telegramAPIURL = "https://api.telegram.org"
+
+// telegramMessage is the request body of the Telegram sendMessage method.
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// sendTelegram sends a text message to the configured Telegram chat.
+func (hm *HeatingManager) sendTelegram(text string) error {
+	config := hm.currentConfig()
+	body, err := json.Marshal(telegramMessage{ChatID: config.TelegramChatID, Text: text})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Telegram message: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", telegramAPIURL, config.TelegramBotToken)
+	resp, err := hm.httpPost(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The request URL contains the bot token, so only report the underlying cause.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to send Telegram message: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send Telegram message: status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendTelegram(t *testing.T) {
+	var received telegramMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode message: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	oldURL := telegramAPIURL
+	telegramAPIURL = ts.URL
+	defer func() { telegramAPIURL = oldURL }()
+
+	manager := newTestManager(t)
+	manager.Config.TelegramBotToken = "secret"
+	manager.Config.TelegramChatID = "12345"
+
+	manager.notify(context.Background(), eventHeatingOn, reasonWeeklyLegionella)
+	if received.ChatID != "12345" {
+		t.Errorf("Expected chat ID 12345, got %q", received.ChatID)
+	}
+	if received.Text == "" {
+		t.Error("Expected a message text")
+	}
+}
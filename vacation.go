@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SetVacationMode enables or disables vacation mode, overriding the vacationMode config field, and persists it.
+func (hm *HeatingManager) SetVacationMode(on bool) error {
+	hm.mu.Lock()
+	hm.vacationOverride = &on
+	hm.mu.Unlock()
+	logVacationMode(on)
+	return hm.saveState()
+}
+
+// isVacationMode reports whether vacation mode is on, set via the API or else by the config.
+func (hm *HeatingManager) isVacationMode() bool {
+	hm.mu.Lock()
+	override := hm.vacationOverride
+	hm.mu.Unlock()
+	if override != nil {
+		return *override
+	}
+	return hm.currentConfig().VacationMode
+}
+
+// reloadVacationMode drops the API override once a reloaded config changes vacationMode,
+// so the new config value takes effect.
+func (hm *HeatingManager) reloadVacationMode(old, config Config) {
+	if config.VacationMode == old.VacationMode {
+		return
+	}
+	hm.mu.Lock()
+	hm.vacationOverride = nil
+	hm.mu.Unlock()
+	logVacationMode(config.VacationMode)
+	if err := hm.saveState(); err != nil {
+		slog.Warn("Failed to save state after vacation mode change", "err", err)
+	}
+}
+
+// logVacationMode logs a vacation mode transition.
+func logVacationMode(on bool) {
+	if on {
+		slog.Info("Vacation mode enabled, PV surplus heating suspended, the weekly check keeps running")
+	} else {
+		slog.Info("Vacation mode disabled, PV surplus heating resumed")
+	}
+}
+
+// suspendPVHeating turns off heating started by PV surplus while vacation mode is on.
+// A running weekly legionella cycle is left alone.
+func (hm *HeatingManager) suspendPVHeating(ctx context.Context, config Config) {
+	hm.mu.Lock()
+	pvHeating := hm.pvHeating && hm.cancelHeating == nil
+	hm.mu.Unlock()
+	if !pvHeating {
+		return
+	}
+	slog.InfoContext(ctx, "Vacation mode on, turning off PV surplus heating")
+	if err := hm.switchHeatingOff(ctx, hm.heatingController(config.ShellyHeatingOnURL, config.ShellyHeatingOffURL)); err != nil {
+		slog.ErrorContext(ctx, "Failed to turn off PV surplus heating for vacation mode", "err", err)
+		return
+	}
+	hm.setPVHeating(false)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestControlPVSurplusVacationMode(t *testing.T) {
+	var surplusCalls, onCalls, offCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/surplus":
+			surplusCalls++
+			_, _ = w.Write([]byte("2500"))
+		case "/on":
+			onCalls++
+		case "/off":
+			offCalls++
+		}
+	}))
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	manager.Config.PVSurplusURL = ts.URL + "/surplus"
+	manager.Config.PVSurplusThresholdWatts = 2000
+	manager.Config.ShellyHeatingOnURL = ts.URL + "/on"
+	manager.Config.ShellyHeatingOffURL = ts.URL + "/off"
+
+	manager.controlPVSurplus(context.Background())
+	if onCalls != 1 || !manager.pvHeating {
+		t.Fatalf("Expected heating to be turned on for PV surplus, got %d on calls", onCalls)
+	}
+
+	if err := manager.SetVacationMode(true); err != nil {
+		t.Fatalf("SetVacationMode returned an error: %v", err)
+	}
+	manager.controlPVSurplus(context.Background())
+	manager.controlPVSurplus(context.Background())
+	if offCalls != 1 || manager.pvHeating {
+		t.Errorf("Expected PV surplus heating to be turned off once, got %d off calls", offCalls)
+	}
+	if surplusCalls != 1 || onCalls != 1 {
+		t.Errorf("Expected no PV surplus heating in vacation mode, got %d surplus reads and %d on calls", surplusCalls, onCalls)
+	}
+
+	if err := manager.SetVacationMode(false); err != nil {
+		t.Fatalf("SetVacationMode returned an error: %v", err)
+	}
+	manager.controlPVSurplus(context.Background())
+	if onCalls != 2 {
+		t.Errorf("Expected PV surplus heating to resume, got %d on calls", onCalls)
+	}
+}
+
+func TestVacationModeKeepsWeeklyCheck(t *testing.T) {
+	manager := newTestManager(t)
+	defer manager.cancelHeatingOff()
+	manager.Config.VacationMode = true
+	controller := &stubController{}
+
+	if err := manager.weeklyCheck(context.Background(), controller, false); err != nil {
+		t.Fatalf("weeklyCheck returned an error: %v", err)
+	}
+	if controller.onCalls.Load() != 1 {
+		t.Errorf("Expected the weekly heating to run in vacation mode, got %d on calls", controller.onCalls.Load())
+	}
+}
+
+func TestVacationModePersisted(t *testing.T) {
+	manager := newTestManager(t)
+	manager.Config.VacationMode = true
+	if err := manager.SetVacationMode(false); err != nil {
+		t.Fatalf("SetVacationMode returned an error: %v", err)
+	}
+
+	restarted := newTestManager(t)
+	restarted.StateFile = manager.StateFile
+	restarted.Config.VacationMode = true
+	restarted.restoreState()
+	if restarted.isVacationMode() {
+		t.Error("Expected the API override to survive a restart")
+	}
+}
+
+func TestReloadConfigVacationMode(t *testing.T) {
+	manager := newTestManager(t)
+	if err := manager.SetVacationMode(true); err != nil {
+		t.Fatalf("SetVacationMode returned an error: %v", err)
+	}
+
+	reload := func(vacationMode bool) {
+		t.Helper()
+		config := manager.currentConfig()
+		config.VacationMode = vacationMode
+		if err := manager.reloadConfig(writeTestConfig(t, config), false); err != nil {
+			t.Fatalf("reloadConfig returned an error: %v", err)
+		}
+	}
+
+	reload(false)
+	if !manager.isVacationMode() {
+		t.Error("Expected an unchanged vacationMode to keep the API override")
+	}
+
+	reload(true)
+	reload(false)
+	if manager.isVacationMode() {
+		t.Error("Expected a changed vacationMode to replace the API override")
+	}
+}
+
+func TestHandleVacation(t *testing.T) {
+	manager := newTestManager(t)
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/vacation", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if !manager.isVacationMode() || !manager.status().VacationMode {
+		t.Error("Expected vacation mode to be enabled")
+	}
+
+	rec = httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/vacation", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	if manager.isVacationMode() {
+		t.Error("Expected vacation mode to be disabled")
+	}
+}
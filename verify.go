@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// verificationTarget returns the temperature the tank must reach for a weekly heating run to count as effective:
+// PasteurizationTemp if set, otherwise the temperature threshold.
+func (c Config) verificationTarget() float64 {
+	if c.PasteurizationTemp != 0 {
+		return c.PasteurizationTemp
+	}
+	return c.TemperatureThreshold
+}
+
+// scheduleVerification reads the temperature VerifyAfterMinutes after the weekly heating turned on and sends a
+// critical notification if the tank did not reach the verification target or could not be read.
+// The read is abandoned by cancelHeatingOff, but not by the heating cycle ending on its own.
+func (hm *HeatingManager) scheduleVerification(ctx context.Context) {
+	config := hm.currentConfig()
+	if config.VerifyAfterMinutes == 0 || config.DryRun {
+		return
+	}
+	delay := time.Duration(config.VerifyAfterMinutes) * time.Minute
+
+	ctx, cancel := context.WithCancel(detach(ctx))
+	hm.mu.Lock()
+	if hm.cancelVerify != nil {
+		hm.cancelVerify()
+	}
+	hm.cancelVerify = cancel
+	hm.mu.Unlock()
+
+	hm.heatingWG.Add(1)
+	go func() {
+		defer hm.heatingWG.Done()
+		defer cancel()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "Heating verification cancelled")
+			return
+		case <-timer.C:
+		}
+		hm.verifyHeating(ctx)
+	}()
+}
+
+// verifyHeating reads the temperature and notifies if it is below the verification target.
+func (hm *HeatingManager) verifyHeating(ctx context.Context) {
+	config := hm.currentConfig()
+	target := config.verificationTarget()
+	temperature, _, err := hm.readTemperature(ctx, config.ShellyURLs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read the temperature to verify the heating run", "event", eventHeatingNotVerified, "err", err)
+		hm.notify(ctx, eventHeatingNotVerified, errorReason(err, reasonVerifyReadFailed))
+		return
+	}
+	if temperature < target {
+		slog.ErrorContext(ctx, "Tank did not reach the target temperature after heating", "event", eventHeatingNotVerified, "temperature", temperature, "target", target, "unit", config.TemperatureUnit, "after", time.Duration(config.VerifyAfterMinutes)*time.Minute)
+		hm.notify(ctx, eventHeatingNotVerified, reasonTargetNotReached)
+		return
+	}
+	slog.InfoContext(ctx, "Heating run verified", "temperature", temperature, "target", target, "unit", config.TemperatureUnit)
+}
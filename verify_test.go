@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// notificationRecorder starts a webhook server recording the received notifications.
+func notificationRecorder(t *testing.T, manager *HeatingManager) func() []Notification {
+	var (
+		mu       sync.Mutex
+		received []Notification
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(ts.Close)
+	manager.Config.NotifyURL = ts.URL
+	return func() []Notification {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]Notification(nil), received...)
+	}
+}
+
+func TestVerifyHeating(t *testing.T) {
+	tests := []struct {
+		name   string
+		source stubSource
+		reason string
+	}{
+		{"target reached", stubSource{temperature: 61}, ""},
+		{"target not reached", stubSource{temperature: 48}, reasonTargetNotReached},
+		{"read failed", stubSource{err: errors.New("sensor offline")}, reasonVerifyReadFailed},
+	}
+	for _, tt := range tests {
+		manager := newTestManager(t)
+		received := notificationRecorder(t, manager)
+		manager.Config.PasteurizationTemp = 60
+		manager.Config.TemperatureThreshold = 45
+		manager.TemperatureSources = []TemperatureSource{tt.source}
+
+		manager.verifyHeating(context.Background())
+		notifications := received()
+		if tt.reason == "" {
+			if len(notifications) != 0 {
+				t.Errorf("%s: expected no notification, got %+v", tt.name, notifications)
+			}
+			continue
+		}
+		if len(notifications) != 1 || notifications[0].Event != eventHeatingNotVerified || notifications[0].Reason != tt.reason {
+			t.Errorf("%s: expected a %s notification with reason %s, got %+v", tt.name, eventHeatingNotVerified, tt.reason, notifications)
+			continue
+		}
+		if notifications[0].Severity != severityCritical {
+			t.Errorf("%s: expected severity %q, got %q", tt.name, severityCritical, notifications[0].Severity)
+		}
+	}
+}
+
+func TestVerificationTarget(t *testing.T) {
+	config := testConfig()
+	config.TemperatureThreshold = 45
+	if target := config.verificationTarget(); target != 45 {
+		t.Errorf("Expected the threshold 45 without a pasteurization temperature, got %v", target)
+	}
+	config.PasteurizationTemp = 60
+	if target := config.verificationTarget(); target != 60 {
+		t.Errorf("Expected the pasteurization temperature 60, got %v", target)
+	}
+}
+
+func TestScheduleVerificationCancelled(t *testing.T) {
+	manager := newTestManager(t)
+	received := notificationRecorder(t, manager)
+	manager.Config.VerifyAfterMinutes = 60
+	manager.TemperatureSources = []TemperatureSource{stubSource{temperature: 20}}
+
+	manager.scheduleVerification(context.Background())
+	manager.cancelHeatingOff()
+	manager.heatingWG.Wait()
+	if notifications := received(); len(notifications) != 0 {
+		t.Errorf("Expected no notification after cancelling the verification, got %+v", notifications)
+	}
+}
+
+func TestScheduleVerificationDisabled(t *testing.T) {
+	manager := newTestManager(t)
+	manager.scheduleVerification(context.Background())
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+	if manager.cancelVerify != nil {
+		t.Error("Expected no verification to be scheduled with verifyAfterMinutes 0")
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "fmt"
+
+// Build information, set at build time via
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// versionString returns the build information in a single line.
+func versionString() string {
+	return fmt.Sprintf("heating_manager %s (commit %s, built %s)", version, commit, buildDate)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// webSocketClientID identifies the manager as the source of its RPC requests, so the device replies to it.
+	webSocketClientID = "pv_heating_manager"
+	// sourceWebSocket is the source reported for readings pushed over the WebSocket.
+	sourceWebSocket = "websocket"
+	// temperatureComponentPrefix starts the status keys of Gen2 temperature components, e.g. "temperature:100".
+	temperatureComponentPrefix = "temperature:"
+)
+
+// monitorWebSocket records the temperature pushed by the Shelly WebSocket until the context is cancelled.
+// While the connection is down, the temperature is polled every poll interval, each time after another
+// connection attempt. Clearing shellyWebSocketURL by a reload switches to polling for good.
+func (hm *HeatingManager) monitorWebSocket(ctx context.Context) {
+	for {
+		url := hm.currentConfig().ShellyWebSocketURL
+		if url == "" {
+			hm.pollTemperature(ctx)
+			return
+		}
+		err := hm.streamTemperature(ctx, url)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Shelly WebSocket unavailable, polling the temperature instead", "url", url, "err", err)
+		hm.retryHeatingOff(ctx)
+		if hm.shouldCheckTemperature() {
+			hm.checkTemperature(ctx, hm.currentConfig().ShellyURLs)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(hm.nextCheckDelay()):
+		}
+	}
+}
+
+// streamTemperature connects to the Shelly WebSocket at url and records the pushed temperature until
+// the connection fails or the context is cancelled. The full status is requested on connecting and then
+// every poll interval, since the device only pushes changes; a connection silent for two poll intervals
+// counts as dropped. The first reading is recorded right away and then the latest pushed one every poll
+// interval, so smoothing, history and state advance per check like polling; every push is still checked
+// against the maximum safe temperature at once.
+func (hm *HeatingManager) streamTemperature(ctx context.Context, url string) error {
+	config := hm.currentConfig()
+	tlsConfig, err := shellyTLSConfig(config)
+	if err != nil {
+		return err
+	}
+	timeout := time.Duration(config.HTTPTimeout) * time.Second
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment, HandshakeTimeout: timeout, TLSClientConfig: tlsConfig}
+	conn, resp, err := dialer.DialContext(ctx, url, http.Header{"User-Agent": {config.userAgent()}})
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return fmt.Errorf("failed to connect to WebSocket: %w", &StatusError{StatusCode: resp.StatusCode})
+		}
+		return fmt.Errorf("failed to connect to WebSocket: %w", unreachable(err))
+	}
+	slog.Info("Connected to Shelly WebSocket, the temperature is pushed instead of polled", "url", url)
+
+	connCtx, cancel := context.WithCancel(ctx)
+	readings := make(chan float64)
+	readErr := make(chan error, 1)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		hm.requestStatus(connCtx, conn)
+	}()
+	go func() {
+		defer wg.Done()
+		readErr <- hm.readStatusFrames(connCtx, conn, url, readings)
+	}()
+	// Closing the connection ends a pending read and the status requests.
+	stop := context.AfterFunc(connCtx, func() { conn.Close() })
+	defer func() {
+		stop()
+		cancel()
+		conn.Close()
+		wg.Wait()
+	}()
+
+	checkTimer := time.NewTimer(hm.nextCheckDelay())
+	defer checkTimer.Stop()
+	var (
+		latest   float64
+		received bool
+	)
+	for {
+		configChanged := hm.configChangedChan()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case temperature := <-readings:
+			readCtx := withCorrelationID(ctx)
+			slog.DebugContext(readCtx, "Temperature pushed by Shelly WebSocket", "source", sourceWebSocket, "temperature", temperature)
+			if received {
+				latest = temperature
+				hm.enforceSafetyCutoff(readCtx, temperature)
+				continue
+			}
+			latest, received = temperature, true
+			hm.recordPushedTemperature(readCtx, latest)
+		case <-configChanged:
+			if !checkTimer.Stop() {
+				<-checkTimer.C
+			}
+			checkTimer.Reset(hm.nextCheckDelay())
+		case <-checkTimer.C:
+			hm.retryHeatingOff(ctx)
+			if received && hm.shouldCheckTemperature() {
+				hm.recordPushedTemperature(withCorrelationID(ctx), latest)
+			}
+			checkTimer.Reset(hm.nextCheckDelay())
+		}
+	}
+}
+
+// recordPushedTemperature records a temperature pushed by the WebSocket like a polled reading.
+func (hm *HeatingManager) recordPushedTemperature(ctx context.Context, temperature float64) {
+	hm.checkMu.Lock()
+	defer hm.checkMu.Unlock()
+	hm.recordTemperature(ctx, hm.currentConfig(), temperature, sourceWebSocket)
+}
+
+// readStatusFrames reads the messages of the WebSocket at url and sends every temperature they report,
+// calibrated and rounded, to readings until reading fails or the context is cancelled.
+func (hm *HeatingManager) readStatusFrames(ctx context.Context, conn *websocket.Conn, url string, readings chan<- float64) error {
+	for {
+		config := hm.currentConfig()
+		timeout := time.Duration(config.HTTPTimeout) * time.Second
+		if err := conn.SetReadDeadline(time.Now().Add(2*hm.pollInterval() + timeout)); err != nil {
+			return unreachable(err)
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("failed to read from WebSocket: %w", unreachable(err))
+		}
+
+		temperature, ok, err := parseStatusFrame(data, config.TemperatureUnit)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case readings <- config.roundTemperature(temperature + config.temperatureOffset(url)):
+		}
+	}
+}
+
+// requestStatus requests the full device status right away and then every poll interval
+// until the context is cancelled or a request fails.
+func (hm *HeatingManager) requestStatus(ctx context.Context, conn *websocket.Conn) {
+	for id := 1; ; id++ {
+		request := map[string]any{"id": id, "src": webSocketClientID, "method": "Shelly.GetStatus"}
+		if err := conn.WriteJSON(request); err != nil {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(hm.pollInterval()):
+		}
+	}
+}
+
+// statusFrame is a message on the Gen2 WebSocket: a status notification or the response to Shelly.GetStatus.
+type statusFrame struct {
+	Method string                     `json:"method"`
+	Params map[string]json.RawMessage `json:"params"`
+	Result map[string]json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// temperatureComponent is the status of a Gen2 temperature component; notifications only carry changed fields.
+type temperatureComponent struct {
+	TC *float64 `json:"tC"`
+	TF *float64 `json:"tF"`
+}
+
+// parseStatusFrame returns the highest temperature in unit reported by a WebSocket message, and whether
+// it reported any; other notifications are ignored. An error response, e.g. because the device requires
+// authentication, fails with its message.
+func parseStatusFrame(data []byte, unit string) (float64, bool, error) {
+	var frame statusFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return 0, false, fmt.Errorf("failed to parse WebSocket message: %w: %v", ErrParse, err)
+	}
+	if frame.Error != nil {
+		return 0, false, fmt.Errorf("status request failed: %d %s", frame.Error.Code, frame.Error.Message)
+	}
+
+	status := frame.Result
+	if frame.Method == "NotifyStatus" || frame.Method == "NotifyFullStatus" {
+		status = frame.Params
+	}
+	var (
+		maxTemperature float64
+		found          bool
+	)
+	for key, raw := range status {
+		if !strings.HasPrefix(key, temperatureComponentPrefix) {
+			continue
+		}
+		var component temperatureComponent
+		if err := json.Unmarshal(raw, &component); err != nil {
+			return 0, false, fmt.Errorf("failed to parse %s: %w: %v", key, ErrParse, err)
+		}
+		reading := component.TC
+		if unit == unitFahrenheit {
+			reading = component.TF
+		}
+		if reading == nil {
+			continue
+		}
+		if !found || *reading > maxTemperature {
+			maxTemperature = *reading
+			found = true
+		}
+	}
+	return maxTemperature, found, nil
+}
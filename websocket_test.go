@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseStatusFrame(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		unit    string
+		want    float64
+		wantOK  bool
+		wantErr bool
+	}{
+		{"status response", `{"id":1,"src":"shellyplus1","result":{"switch:0":{"output":false},"temperature:100":{"id":100,"tC":52.5,"tF":126.5}}}`, unitCelsius, 52.5, true, false},
+		{"notification in Fahrenheit", `{"method":"NotifyStatus","params":{"ts":1.7e9,"temperature:100":{"id":100,"tC":52.5,"tF":126.5}}}`, unitFahrenheit, 126.5, true, false},
+		{"hottest of several sensors", `{"method":"NotifyFullStatus","params":{"temperature:100":{"tC":48},"temperature:101":{"tC":55.5}}}`, unitCelsius, 55.5, true, false},
+		{"other notification", `{"method":"NotifyStatus","params":{"switch:0":{"output":true}}}`, unitCelsius, 0, false, false},
+		{"event", `{"method":"NotifyEvent","params":{"events":[]}}`, unitCelsius, 0, false, false},
+		{"error response", `{"id":1,"error":{"code":401,"message":"unauthorized"}}`, unitCelsius, 0, false, true},
+		{"invalid message", `<html>`, unitCelsius, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseStatusFrame([]byte(tt.data), tt.unit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Expected %v (%v), got %v (%v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+	if _, _, err := parseStatusFrame([]byte(`<html>`), unitCelsius); !errors.Is(err, ErrParse) {
+		t.Errorf("Expected ErrParse for an invalid message, got %v", err)
+	}
+}
+
+// waitForTemperature waits until the manager's current temperature is want.
+func waitForTemperature(t *testing.T, manager *HeatingManager, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if temperature, _ := manager.CurrentTemperature(); temperature == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	temperature, _ := manager.CurrentTemperature()
+	t.Fatalf("Expected temperature %v, got %v", want, temperature)
+}
+
+// newWebSocketServer serves a Shelly sensor reporting 45.0 °C at /temp and a WebSocket answering the
+// first status request with 50.0 °C and then sending the messages of push. Closing push drops the
+// connection and refuses further ones.
+func newWebSocketServer(t *testing.T, push chan string) *httptest.Server {
+	t.Helper()
+	var dropped atomic.Bool
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/temp" {
+			_, _ = w.Write([]byte(`{"id":0,"tC":45.0}`))
+			return
+		}
+		if dropped.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var request map[string]any
+		if err := conn.ReadJSON(&request); err != nil || request["method"] != "Shelly.GetStatus" {
+			t.Errorf("Expected a Shelly.GetStatus request, got %v (%v)", request, err)
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"id":1,"result":{"temperature:100":{"id":100,"tC":50.0,"tF":122.0}}}`))
+		for message := range push {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(message))
+		}
+		dropped.Store(true)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// startWebSocketMonitoring starts monitoring the temperature until the test ends.
+func startWebSocketMonitoring(t *testing.T, manager *HeatingManager) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		manager.StartTemperatureMonitoring(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestMonitorWebSocket(t *testing.T) {
+	push := make(chan string)
+	ts := newWebSocketServer(t, push)
+
+	manager := newTestManager(t)
+	manager.CheckInterval = 20 * time.Millisecond
+	manager.Config.ShellyURLs = []string{ts.URL + "/temp"}
+	manager.Config.ShellyWebSocketURL = "ws" + strings.TrimPrefix(ts.URL, "http") + "/rpc"
+	manager.Config.TemperatureOffset = 0.5
+	startWebSocketMonitoring(t, manager)
+
+	waitForTemperature(t, manager, 50.5)
+	push <- `{"method":"NotifyStatus","params":{"temperature:100":{"id":100,"tC":61.0,"tF":141.8}}}`
+	waitForTemperature(t, manager, 61.5)
+	if !manager.isTemperatureExceeded() {
+		t.Error("Expected a pushed temperature above the threshold to set the threshold flag")
+	}
+
+	// Once the connection drops, the sensor is polled over HTTP.
+	close(push)
+	waitForTemperature(t, manager, 45.5)
+}
+
+func TestStreamTemperatureRecordsPerCheck(t *testing.T) {
+	push := make(chan string)
+	ts := newWebSocketServer(t, push)
+	defer close(push)
+
+	manager := newTestManager(t)
+	manager.CheckInterval = time.Hour
+	manager.Config.ShellyURLs = []string{ts.URL + "/temp"}
+	manager.Config.ShellyWebSocketURL = "ws" + strings.TrimPrefix(ts.URL, "http") + "/rpc"
+	manager.Config.MaxSafeTemperature = 85
+	controller := &stubController{}
+	manager.HeatingController = controller
+	startWebSocketMonitoring(t, manager)
+
+	waitForTemperature(t, manager, 50)
+	push <- `{"method":"NotifyStatus","params":{"temperature:100":{"id":100,"tC":52.0,"tF":125.6}}}`
+	push <- `{"method":"NotifyStatus","params":{"temperature:100":{"id":100,"tC":90.0,"tF":194.0}}}`
+
+	// The cutoff acts on the push at once, while the reading waits for the next check.
+	deadline := time.Now().Add(2 * time.Second)
+	for controller.offCalls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if controller.offCalls.Load() == 0 {
+		t.Fatal("Expected a push above the maximum safe temperature to force the heating off")
+	}
+	if temperature, _ := manager.CurrentTemperature(); temperature != 50 {
+		t.Errorf("Expected pushes between checks to leave the recorded temperature at 50, got %v", temperature)
+	}
+	if readings := manager.RecentReadings(0); len(readings) != 1 {
+		t.Errorf("Expected only the first pushed reading to be recorded, got %v", readings)
+	}
+}
+
+func TestStreamTemperatureBadHandshake(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	manager := newTestManager(t)
+	err := manager.streamTemperature(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http")+"/rpc")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a StatusError with status code 404, got %v", err)
+	}
+}
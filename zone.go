@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultZoneName names the single zone of a config without zones.
+const defaultZoneName = "default"
+
+// zoneNamePattern restricts zone names to characters safe in file names and URL paths.
+var zoneNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ZoneConfig configures one of several independent tanks. The sensors and the relay are required;
+// unset thresholds and schedule settings are inherited from the top-level config.
+type ZoneConfig struct {
+	Name                   string   `json:"name"`                   // Name of the zone, used in the API, notifications and file names.
+	ShellyURL              string   `json:"shellyTempURL"`          // URL of the zone's temperature sensor.
+	ShellyURLs             []string `json:"shellyTempURLs"`         // URLs of all the zone's temperature sensors, the hottest one is used.
+	ShellyHeatingOnURL     string   `json:"shellyHeatingOnURL"`     // URL turning the zone's heating on.
+	ShellyHeatingOffURL    string   `json:"shellyHeatingOffURL"`    // URL turning the zone's heating off.
+	ShellyRelayURL         string   `json:"shellyRelayURL"`         // Base URL of the zone's Shelly relay, derives missing heating on/off URLs.
+	ShellyRelayID          int      `json:"shellyRelayID"`          // ID of the relay switched when shellyRelayURL is set.
+	ShellyStatusURL        string   `json:"shellyStatusURL"`        // URL of the zone's relay status, empty skips the check.
+	ShellyPowerURL         string   `json:"shellyPowerURL"`         // URL reporting the power draw of the zone's heating element, empty skips the power check.
+	ShellyWebSocketURL     string   `json:"shellyWebSocketURL"`     // Gen2 WebSocket RPC endpoint of the zone's sensor device, empty polls.
+	TemperatureThreshold   float64  `json:"temperatureThreshold"`   // Threshold of the zone, 0 inherits it.
+	TemperatureTurnOff     float64  `json:"temperatureTurnOff"`     // Turn-off temperature of the zone, 0 inherits it.
+	HeatingDurationMinutes int      `json:"heatingDurationMinutes"` // Duration of the zone's heating run in minutes, 0 inherits it.
+	WeeklyCheckInterval    int      `json:"weeklyCheckInterval"`    // Hours between the zone's weekly checks, 0 inherits it.
+	WeeklyCheckWeekday     string   `json:"weeklyCheckWeekday"`     // Weekday of the zone's weekly check; set together with weeklyCheckHour, empty inherits both.
+	WeeklyCheckHour        int      `json:"weeklyCheckHour"`        // Hour of day of the zone's weekly check when weeklyCheckWeekday is set.
+	WeeklyCheckCron        string   `json:"weeklyCheckCron"`        // Cron expression of the zone's weekly check, empty inherits it unless weeklyCheckWeekday is set.
+}
+
+// zoneConfig returns the config of a zone: the top-level config with the zone's devices and overrides.
+// The top-level temperature fallback sensor and PV surplus control are left out,
+// and the history file gets the zone name appended.
+func (c Config) zoneConfig(zone ZoneConfig) Config {
+	config := c
+	config.Zones = nil
+	config.ShellyURL = zone.ShellyURL
+	config.ShellyURLs = zone.ShellyURLs
+	config.ShellyHeatingOnURL = zone.ShellyHeatingOnURL
+	config.ShellyHeatingOffURL = zone.ShellyHeatingOffURL
+	config.ShellyRelayURL = zone.ShellyRelayURL
+	config.ShellyRelayID = zone.ShellyRelayID
+	config.ShellyStatusURL = zone.ShellyStatusURL
+	config.ShellyPowerURL = zone.ShellyPowerURL
+	config.ShellyWebSocketURL = zone.ShellyWebSocketURL
+	config.ShellyTempFallbackURL = ""
+	config.PVSurplusURL = ""
+	config.HistoryFile = zoneFile(c.HistoryFile, zone.Name)
+	if zone.TemperatureThreshold != 0 {
+		config.TemperatureThreshold = zone.TemperatureThreshold
+	}
+	if zone.TemperatureTurnOff != 0 {
+		config.TemperatureTurnOff = zone.TemperatureTurnOff
+	}
+	if zone.HeatingDurationMinutes != 0 {
+		config.HeatingDurationMinutes = zone.HeatingDurationMinutes
+	}
+	if zone.WeeklyCheckInterval != 0 {
+		config.WeeklyCheckInterval = zone.WeeklyCheckInterval
+	}
+	if zone.WeeklyCheckWeekday != "" {
+		config.WeeklyCheckWeekday = zone.WeeklyCheckWeekday
+		config.WeeklyCheckHour = zone.WeeklyCheckHour
+		config.WeeklyCheckCron = ""
+	}
+	if zone.WeeklyCheckCron != "" {
+		config.WeeklyCheckCron = zone.WeeklyCheckCron
+		config.WeeklyCheckWeekday = ""
+	}
+	return config
+}
+
+// validateZones checks that every zone has a unique name and a valid config of its own.
+// PV surplus control still switches the top-level relay, which must then be configured.
+func (c *Config) validateZones() error {
+	// No zone's sensor supervises the top-level relay, so PV surplus heating could not be cut off.
+	if c.PVSurplusURL != "" {
+		return fmt.Errorf("invalid config: pvSurplusURL cannot be used with zones")
+	}
+	names := make(map[string]bool, len(c.Zones))
+	for i, zone := range c.Zones {
+		if !zoneNamePattern.MatchString(zone.Name) {
+			return fmt.Errorf("invalid config: zones[%d].name must only contain letters, digits, - and _, got %q", i, zone.Name)
+		}
+		if names[zone.Name] {
+			return fmt.Errorf("invalid config: zones[%d].name %q is used twice", i, zone.Name)
+		}
+		names[zone.Name] = true
+
+		config := c.zoneConfig(zone)
+		config.setDefaults()
+		if err := config.validate(); err != nil {
+			return fmt.Errorf("zone %s: %w", zone.Name, err)
+		}
+	}
+	return nil
+}
+
+// zoneFile returns path with the zone name inserted before its extension, e.g. state-tank2.json.
+// An empty path stays empty.
+func zoneFile(path, zone string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + zone + ext
+}
+
+// newZoneManagers creates a manager for each configured zone, with state files named after the zone.
+func (hm *HeatingManager) newZoneManagers() error {
+	config := hm.currentConfig()
+	for _, zone := range config.Zones {
+		zm, err := newHeatingManager(config.zoneConfig(zone))
+		if err != nil {
+			return fmt.Errorf("zone %s: %w", zone.Name, err)
+		}
+		zm.Zone = zone.Name
+		zm.LogOutput = hm.LogOutput
+		zm.StateFile = zoneFile(hm.StateFile, zone.Name)
+		zm.LastCheckFile = zoneFile(hm.LastCheckFile, zone.Name)
+		zm.restoreState()
+		hm.zones = append(hm.zones, zm)
+	}
+	return nil
+}
+
+// zoneManagers returns the managers of the configured zones, or the manager itself as the default zone.
+func (hm *HeatingManager) zoneManagers() []*HeatingManager {
+	if len(hm.zones) == 0 {
+		return []*HeatingManager{hm}
+	}
+	return hm.zones
+}
+
+// zoneManager returns the manager of the named zone, or nil if there is none.
+func (hm *HeatingManager) zoneManager(name string) *HeatingManager {
+	for _, zm := range hm.zoneManagers() {
+		if zm.zoneName() == name {
+			return zm
+		}
+	}
+	return nil
+}
+
+// zoneName returns the name of the zone heated by the manager.
+func (hm *HeatingManager) zoneName() string {
+	if hm.Zone == "" {
+		return defaultZoneName
+	}
+	return hm.Zone
+}
+
+// applyZoneConfigs applies a reloaded config to the zone managers. Added or removed zones
+// only take effect after a restart; a zone missing from the reloaded config keeps its config.
+func (hm *HeatingManager) applyZoneConfigs(config Config) {
+	for _, zm := range hm.zones {
+		for _, zone := range config.Zones {
+			if zone.Name == zm.Zone {
+				zoneConfig := config.zoneConfig(zone)
+				zoneConfig.setDefaults()
+				zm.applyConfig(zoneConfig)
+			}
+		}
+	}
+}
+
+// zonesResponse is the JSON body returned by GET /zones.
+type zonesResponse struct {
+	Zones []statusResponse `json:"zones"`
+}
+
+// handleZones reports the status of every zone.
+func (hm *HeatingManager) handleZones(w http.ResponseWriter, r *http.Request) {
+	response := zonesResponse{Zones: []statusResponse{}}
+	for _, zm := range hm.zoneManagers() {
+		response.Zones = append(response.Zones, zm.status())
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// forZone returns a handler serving a request with the manager of the zone named in the path.
+func (hm *HeatingManager) forZone(handle func(*HeatingManager, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zm := hm.zoneManager(r.PathValue("zone"))
+		if zm == nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown zone"})
+			return
+		}
+		handle(zm, w, r)
+	}
+}
+
+// withoutZones returns a handler serving a request with the manager itself, or rejecting it with 409
+// once zones are configured, since the top-level manager then heats no tank of its own.
+func (hm *HeatingManager) withoutZones(handle func(*HeatingManager, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(hm.zones) > 0 {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "zones are configured, use /zones/<name>" + r.URL.Path})
+			return
+		}
+		handle(hm, w, r)
+	}
+}
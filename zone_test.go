@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testZones returns two zones with their own devices, the second one heating on Saturdays.
+func testZones() []ZoneConfig {
+	return []ZoneConfig{
+		{
+			Name:                "tank1",
+			ShellyURL:           "http://127.0.0.1:1/tank1/temperature",
+			ShellyHeatingOnURL:  "http://127.0.0.1:1/tank1/on",
+			ShellyHeatingOffURL: "http://127.0.0.1:1/tank1/off",
+		},
+		{
+			Name:                 "tank2",
+			ShellyURLs:           []string{"http://127.0.0.1:1/tank2/top", "http://127.0.0.1:1/tank2/bottom"},
+			ShellyHeatingOnURL:   "http://127.0.0.1:1/tank2/on",
+			ShellyHeatingOffURL:  "http://127.0.0.1:1/tank2/off",
+			TemperatureThreshold: 50,
+			WeeklyCheckWeekday:   "Saturday",
+			WeeklyCheckHour:      14,
+		},
+	}
+}
+
+// newTestZoneManager returns a manager of the test zones, with all state files in a temporary directory.
+func newTestZoneManager(t *testing.T) *HeatingManager {
+	t.Helper()
+	config := testConfig()
+	config.Zones = testZones()
+	manager, err := NewHeatingManagerWithConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to create HeatingManager: %v", err)
+	}
+	dir := t.TempDir()
+	for _, zone := range manager.zones {
+		zone.StateFile = filepath.Join(dir, "state-"+zone.Zone+".json")
+		zone.LastCheckFile = filepath.Join(dir, "lastCheck-"+zone.Zone+".txt")
+		zone.restoreState()
+	}
+	return manager
+}
+
+func TestZoneConfig(t *testing.T) {
+	config := testConfig()
+	config.HistoryFile = "history.csv"
+	config.ShellyTempFallbackURL = "http://127.0.0.1:1/fallback"
+	zones := testZones()
+
+	zone1 := config.zoneConfig(zones[0])
+	if zone1.ShellyURL != zones[0].ShellyURL || zone1.ShellyHeatingOnURL != zones[0].ShellyHeatingOnURL {
+		t.Errorf("Expected the zone's devices, got %q and %q", zone1.ShellyURL, zone1.ShellyHeatingOnURL)
+	}
+	if zone1.TemperatureThreshold != config.TemperatureThreshold || zone1.WeeklyCheckInterval != config.WeeklyCheckInterval {
+		t.Error("Expected unset zone settings to be inherited")
+	}
+	if zone1.HistoryFile != "history-tank1.csv" {
+		t.Errorf("Expected a history file per zone, got %q", zone1.HistoryFile)
+	}
+	if zone1.ShellyTempFallbackURL != "" {
+		t.Error("Expected the top-level fallback sensor not to be inherited")
+	}
+
+	config.WeeklyCheckCron = "@weekly"
+	if zone1 := config.zoneConfig(zones[0]); zone1.WeeklyCheckCron != "@weekly" {
+		t.Errorf("Expected tank1 to inherit the cron schedule, got %q", zone1.WeeklyCheckCron)
+	}
+	zone2 := config.zoneConfig(zones[1])
+	if zone2.WeeklyCheckCron != "" {
+		t.Errorf("Expected tank2's weekday to replace the inherited cron schedule, got %q", zone2.WeeklyCheckCron)
+	}
+	if zone2.TemperatureThreshold != 50 || zone2.WeeklyCheckWeekday != "Saturday" || zone2.WeeklyCheckHour != 14 {
+		t.Errorf("Expected the zone's threshold and schedule, got %v, %q and %d", zone2.TemperatureThreshold, zone2.WeeklyCheckWeekday, zone2.WeeklyCheckHour)
+	}
+}
+
+func TestZoneFile(t *testing.T) {
+	tests := map[string]string{
+		"state.json":            "state-tank2.json",
+		"/var/lib/pv/state":     "/var/lib/pv/state-tank2",
+		"/var/lib/pv.d/history": "/var/lib/pv.d/history-tank2",
+		"":                      "",
+	}
+	for path, want := range tests {
+		if got := zoneFile(path, "tank2"); got != want {
+			t.Errorf("zoneFile(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestValidateZones(t *testing.T) {
+	tests := map[string]func(zones []ZoneConfig){
+		"empty name":        func(zones []ZoneConfig) { zones[0].Name = "" },
+		"name with a slash": func(zones []ZoneConfig) { zones[0].Name = "tank/1" },
+		"duplicate name":    func(zones []ZoneConfig) { zones[1].Name = zones[0].Name },
+		"missing sensor":    func(zones []ZoneConfig) { zones[0].ShellyURL = "" },
+		"missing relay":     func(zones []ZoneConfig) { zones[1].ShellyHeatingOffURL = "" },
+		"invalid weekday":   func(zones []ZoneConfig) { zones[1].WeeklyCheckWeekday = "Someday" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			config.Zones = testZones()
+			mutate(config.Zones)
+			config.setDefaults()
+			if err := config.validate(); err == nil {
+				t.Error("Expected a validation error")
+			}
+		})
+	}
+
+	config := testConfig()
+	config.ShellyURL = ""
+	config.ShellyHeatingOnURL = ""
+	config.ShellyHeatingOffURL = ""
+	config.Zones = testZones()
+	config.setDefaults()
+	if err := config.validate(); err != nil {
+		t.Errorf("Expected zones to replace the top-level devices, got %v", err)
+	}
+	config = testConfig()
+	config.Zones = testZones()
+	config.PVSurplusURL = "http://127.0.0.1:1/pv"
+	config.setDefaults()
+	if err := config.validate(); err == nil {
+		t.Error("Expected PV surplus control to be rejected with zones")
+	}
+}
+
+func TestNewHeatingManagerWithZones(t *testing.T) {
+	config := testConfig()
+	config.Zones = testZones()
+	manager, err := NewHeatingManagerWithConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to create HeatingManager: %v", err)
+	}
+	var names, stateFiles []string
+	for _, zone := range manager.zoneManagers() {
+		names = append(names, zone.zoneName())
+		stateFiles = append(stateFiles, zone.StateFile)
+	}
+	if want := []string{"tank1", "tank2"}; !slices.Equal(names, want) {
+		t.Errorf("Expected zones %v, got %v", want, names)
+	}
+	if want := []string{"state-tank1.json", "state-tank2.json"}; !slices.Equal(stateFiles, want) {
+		t.Errorf("Expected state files %v, got %v", want, stateFiles)
+	}
+	zone2 := manager.zoneManager("tank2").currentConfig()
+	if !slices.Equal(zone2.ShellyURLs, testZones()[1].ShellyURLs) || zone2.TemperatureThreshold != 50 {
+		t.Errorf("Expected tank2's sensors and threshold, got %v and %v", zone2.ShellyURLs, zone2.TemperatureThreshold)
+	}
+
+	single := newTestManager(t)
+	if zones := single.zoneManagers(); len(zones) != 1 || zones[0] != single || single.zoneName() != defaultZoneName {
+		t.Error("Expected a config without zones to manage a single default zone")
+	}
+}
+
+func TestApplyZoneConfigs(t *testing.T) {
+	manager := newTestZoneManager(t)
+	config := manager.currentConfig()
+	config.TemperatureThreshold = 58
+	config.Zones = slices.Clone(config.Zones)
+	config.Zones[1].TemperatureThreshold = 52
+
+	manager.applyConfig(config)
+	manager.applyZoneConfigs(config)
+	if got := manager.zoneManager("tank1").currentConfig().TemperatureThreshold; got != 58 {
+		t.Errorf("Expected tank1 to inherit the reloaded threshold 58, got %v", got)
+	}
+	if got := manager.zoneManager("tank2").currentConfig().TemperatureThreshold; got != 52 {
+		t.Errorf("Expected tank2's reloaded threshold 52, got %v", got)
+	}
+}
+
+func TestHandleZones(t *testing.T) {
+	manager := newTestZoneManager(t)
+	manager.zones[0].LastTemperature = 52.5
+	manager.zones[1].LastTemperature = 47
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/zones", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	var response zonesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Zones) != 2 {
+		t.Fatalf("Expected two zones, got %d", len(response.Zones))
+	}
+	if zone := response.Zones[1]; zone.Zone != "tank2" || zone.Temperature != 47 || zone.Threshold != 50 {
+		t.Errorf("Unexpected status of tank2: %+v", zone)
+	}
+}
+
+func TestHandleZoneHeatingRun(t *testing.T) {
+	manager := newTestZoneManager(t)
+	zone := manager.zoneManager("tank2")
+	controller := &stubController{}
+	zone.HeatingController = controller
+	defer zone.cancelHeatingOff()
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/zones/tank3/heating/run", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown zone, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/zones/tank2/heating/run", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if calls := controller.onCalls.Load(); calls != 1 {
+		t.Errorf("Expected tank2's heating to be turned on once, got %d", calls)
+	}
+	if !manager.zoneManager("tank1").NextWeeklyCheck().IsZero() {
+		t.Error("Expected tank1's weekly check not to run")
+	}
+}
+
+func TestHandleZoneSkipNext(t *testing.T) {
+	manager := newTestZoneManager(t)
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/heating/skip-next", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for the top-level endpoint with zones, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/zones/tank2/heating/skip-next", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if !manager.zoneManager("tank2").isSkipNextWeekly() || manager.zoneManager("tank1").isSkipNextWeekly() {
+		t.Error("Expected only tank2 to skip its next weekly heating")
+	}
+
+	rec = httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/zones/tank2/heating/skip-next", nil))
+	if rec.Code != http.StatusOK || manager.zoneManager("tank2").isSkipNextWeekly() {
+		t.Errorf("Expected the skip of tank2 to be cleared, got %d", rec.Code)
+	}
+}
+
+func TestZoneEndpointsConflict(t *testing.T) {
+	manager := newTestZoneManager(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/heating/run", nil),
+		httptest.NewRequest(http.MethodDelete, "/heating/skip-next", nil),
+		httptest.NewRequest(http.MethodGet, "/metrics/temperature", nil),
+	} {
+		rec := httptest.NewRecorder()
+		manager.apiHandler().ServeHTTP(rec, req)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected 409 for %s %s with zones, got %d", req.Method, req.URL.Path, rec.Code)
+		}
+	}
+
+	manager.zoneManager("tank1").recordReading(time.Now(), 48)
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/zones/tank1/metrics/temperature", nil))
+	var history temperatureHistoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode history: %v", err)
+	}
+	if len(history.Readings) != 1 || history.Readings[0].Temperature != 48 {
+		t.Errorf("Expected tank1's reading, got %+v", history)
+	}
+}
+
+func TestHandleStatusZones(t *testing.T) {
+	manager := newTestZoneManager(t)
+	manager.zones[1].LastTemperature = 47
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var response zonesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Zones) != 2 || response.Zones[1].Zone != "tank2" || response.Zones[1].Temperature != 47 {
+		t.Errorf("Expected the status of every zone, got %+v", response.Zones)
+	}
+}
+
+func TestHandleDashboardZones(t *testing.T) {
+	manager := newTestZoneManager(t)
+	manager.zones[0].LastTemperature = 52.5
+	manager.zones[0].LastReadTime = time.Now()
+
+	rec := httptest.NewRecorder()
+	manager.apiHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{"<h2>tank1</h2>", "52.5 °C", "<h2>tank2</h2>", "no reading yet", `href="/zones/tank2/metrics/temperature"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q on the dashboard, got %q", want, body)
+		}
+	}
+}
+
+func TestPublishMQTTDiscoveryZone(t *testing.T) {
+	manager := newTestZoneManager(t)
+	zone := manager.zoneManager("tank2")
+	publisher := &recordingPublisher{}
+	if err := zone.publishMQTTDiscovery(publisher); err != nil {
+		t.Fatalf("publishMQTTDiscovery returned an error: %v", err)
+	}
+
+	var sw haDiscovery
+	if err := json.Unmarshal([]byte(publisher.messages["homeassistant/switch/heating_manager/tank2_heating/config"]), &sw); err != nil {
+		t.Fatalf("Failed to decode switch discovery: %v", err)
+	}
+	if sw.CommandTopic != "heating_manager/tank2/heating/set" || sw.UniqueID != "heating_manager_tank2_heating" || sw.AvailabilityTopic != "heating_manager/status" {
+		t.Errorf("Unexpected switch discovery: %+v", sw)
+	}
+
+	zone.mqtt = publisher
+	zone.publishMQTTState(context.Background(), 48)
+	if publisher.messages["heating_manager/tank2/temperature"] != "48.0" {
+		t.Errorf("Expected tank2's temperature on its own topic, got %v", publisher.messages)
+	}
+}
+
+func TestHandleHealthzZones(t *testing.T) {
+	manager := newTestZoneManager(t)
+	manager.zones[0].LastReadTime = time.Now()
+
+	rec := httptest.NewRecorder()
+	manager.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while tank2 has no reading, got %d", rec.Code)
+	}
+	var response zonesHealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Zones) != 2 || response.Zones[0].Status != "ok" || response.Zones[1].Zone != "tank2" || response.Zones[1].Status != "unhealthy" {
+		t.Errorf("Unexpected zone health %+v", response.Zones)
+	}
+
+	manager.zones[1].LastReadTime = time.Now()
+	rec = httptest.NewRecorder()
+	manager.healthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 once every zone has a reading, got %d", rec.Code)
+	}
+}
+
+func TestNotificationMessageZone(t *testing.T) {
+	notification := Notification{Event: eventHeatingOn, Zone: "tank2"}
+	if got, want := notification.Message(), "tank2: Legionella heating turned on."; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}